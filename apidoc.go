@@ -0,0 +1,569 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"journal-backend/apierror"
+	"journal-backend/config"
+	"journal-backend/jobs"
+	"journal-backend/models"
+	"journal-backend/moon"
+	"journal-backend/openapi"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// bearerAuth is the security scheme of the protected routes.
+const bearerAuth = "bearerAuth"
+
+// apiSpec describes every route newRouter registers for cfg. Request and
+// response schemas are derived from the structs the handlers bind and
+// answer with.
+func apiSpec(cfg *config.Config) *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:   "Journal API",
+		Version: "1.0.0",
+		Description: "Journal, moon and relationship check entries of the signed in user. " +
+			"Errors are answered with the Error schema; its code never changes.",
+	})
+	doc.Components.SecuritySchemes[bearerAuth] = &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Supabase access token from /login or /token/refresh.",
+	}
+	addErrorSchema(doc)
+
+	docs := apiDocs{doc}
+	docs.auth()
+	docs.moon()
+	docs.profile()
+	docs.entries()
+	docs.reports()
+	docs.admin()
+	docs.legacy()
+
+	doc.Add(http.MethodGet, "/openapi.json", &openapi.Operation{
+		Summary:   "This document",
+		Tags:      []string{"meta"},
+		Responses: map[string]*openapi.Response{"200": {Description: "OpenAPI 3 document", Content: openapi.JSON(&openapi.Schema{Type: "object"})}},
+	})
+	if cfg.Server.DocsUI {
+		doc.Add(http.MethodGet, "/docs", &openapi.Operation{
+			Summary:     "Docs page",
+			Description: "Renders this document with Swagger UI, which the browser loads from unpkg.com.",
+			Tags:        []string{"meta"},
+			Responses:   map[string]*openapi.Response{"200": {Description: "HTML page rendering this document"}},
+		})
+	}
+
+	return doc
+}
+
+// addErrorSchema adds apierror.Body as the Error schema with the codes of
+// apierror.Codes.
+func addErrorSchema(doc *openapi.Document) {
+	doc.Component("Error", apierror.Body{})
+	schema := doc.Components.Schemas["Error"]
+
+	code := *schema.Properties["code"]
+	code.Enum = nil
+	for _, c := range apierror.Codes() {
+		code.Enum = append(code.Enum, string(c))
+	}
+	schema.Properties["code"] = &code
+}
+
+// apiDocs adds the operations of one group of routes at a time.
+type apiDocs struct {
+	doc *openapi.Document
+}
+
+// operation is what the route groups tell about one operation; add fills
+// in the error responses and the security requirement.
+type operation struct {
+	summary     string
+	description string
+	tag         string
+	protected   bool
+	deprecated  bool
+	params      []openapi.Parameter
+	body        *openapi.Schema
+	// status and response describe the success response. response is nil
+	// for responses without a body.
+	status   int
+	response *openapi.Schema
+	// errors are the statuses answered with an Error besides 500 and, for
+	// protected routes, 401.
+	errors []int
+}
+
+func (d apiDocs) add(method, path string, o operation) {
+	op := &openapi.Operation{
+		Summary:     o.summary,
+		Description: o.description,
+		OperationID: operationID(method, path),
+		Tags:        []string{o.tag},
+		Parameters:  o.params,
+		Deprecated:  o.deprecated,
+		Responses:   make(map[string]*openapi.Response),
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			schema := &openapi.Schema{Type: "string"}
+			if name == "id" {
+				schema.Type = "integer"
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		}
+	}
+	if o.body != nil {
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(o.body)}
+	}
+
+	status := o.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &openapi.Response{Description: http.StatusText(status)}
+	if o.response != nil {
+		success.Content = openapi.JSON(o.response)
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	statuses := append([]int{http.StatusInternalServerError}, o.errors...)
+	if o.protected {
+		statuses = append(statuses, http.StatusUnauthorized)
+		op.Security = []openapi.SecurityRequirement{{bearerAuth: {}}}
+	}
+	for _, status := range statuses {
+		op.Responses[strconv.Itoa(status)] = &openapi.Response{
+			Description: http.StatusText(status),
+			Content:     openapi.JSON(openapi.Ref("Error")),
+		}
+	}
+
+	d.doc.Add(method, path, op)
+}
+
+// operationID names an operation after its method and path, e.g.
+// getJournalEntriesById.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segment = "by-" + name
+		}
+		for _, word := range strings.Split(segment, "-") {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+func query(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func stringSchema(format string, enum ...string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Format: format, Enum: enum}
+}
+
+func intSchema(min, max float64) *openapi.Schema {
+	return &openapi.Schema{Type: "integer", Minimum: &min, Maximum: &max}
+}
+
+// message is the body of the responses that only confirm success.
+var message = openapi.Object(map[string]*openapi.Schema{"message": {Type: "string"}})
+
+func (d apiDocs) auth() {
+	session := openapi.Object(map[string]*openapi.Schema{
+		"message": {Type: "string"},
+		"session": d.doc.Component("Session", types.Session{}),
+	})
+
+	d.add(http.MethodPost, "/register", operation{
+		summary: "Create an account and profile", tag: "auth",
+		body: d.doc.Component("RegisterRequest", RegisterRequest{}),
+		response: openapi.Object(map[string]*openapi.Schema{
+			"message": {Type: "string"},
+			"session": d.doc.SchemaOf(types.User{}),
+		}),
+		errors: []int{http.StatusBadRequest, http.StatusBadGateway},
+	})
+	d.add(http.MethodPost, "/login", operation{
+		summary: "Sign in with email and password", tag: "auth",
+		body:     d.doc.Component("LoginRequest", LoginRequest{}),
+		response: session,
+		errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusBadGateway},
+	})
+	d.add(http.MethodPost, "/token/refresh", operation{
+		summary: "Exchange a refresh token for a new session", tag: "auth",
+		body:     d.doc.Component("RefreshRequest", RefreshRequest{}),
+		response: session,
+		errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusBadGateway},
+	})
+	d.add(http.MethodPost, "/logout", operation{
+		summary: "Sign out and end the session", tag: "auth", protected: true,
+		response: &openapi.Schema{Type: "string"},
+		errors:   []int{http.StatusBadGateway},
+	})
+}
+
+func (d apiDocs) moon() {
+	moment := "RFC 3339 timestamp or YYYY-MM-DD, default now"
+
+	d.add(http.MethodGet, "/moon/calendar", operation{
+		summary: "New and full moons", tag: "moon",
+		params: []openapi.Parameter{
+			query("from", moment, stringSchema("")),
+			query("months", "Months to cover, default 3", intSchema(1, maxCalendarMonths)),
+		},
+		response: openapi.Object(map[string]*openapi.Schema{"events": openapi.ArrayOf(d.doc.Component("MoonEvent", moon.Event{}))}),
+		errors:   []int{http.StatusBadRequest},
+	})
+	d.add(http.MethodGet, "/moon/phase", operation{
+		summary: "Phase and sign of the moon", tag: "moon",
+		params:   []openapi.Parameter{query("at", moment, stringSchema(""))},
+		response: d.doc.Component("MoonPhase", moon.Phase{}),
+		errors:   []int{http.StatusBadRequest},
+	})
+}
+
+func (d apiDocs) profile() {
+	policy := d.doc.Component("LetGoPolicy", models.LetGoPolicy{})
+	policySchema := d.doc.Components.Schemas["LetGoPolicy"]
+	policySchema.Properties["expire_after_hours"] = intSchema(1, models.MaxLetGoExpiryHours)
+	policySchema.Properties["action"] = stringSchema("", models.LetGoDelete, models.LetGoArchive)
+
+	d.add(http.MethodGet, "/profiles", operation{
+		summary: "Usernames of all profiles", tag: "profile", protected: true,
+		response: openapi.ArrayOf(d.doc.SchemaOf(models.Profile{})),
+	})
+	d.add(http.MethodGet, "/profile/let-go-policy", operation{
+		summary: "How long let-go items are kept", tag: "profile", protected: true,
+		response: policy,
+		errors:   []int{http.StatusNotFound},
+	})
+	d.add(http.MethodPut, "/profile/let-go-policy", operation{
+		summary: "Choose how long let-go items are kept", tag: "profile", protected: true,
+		description: fmt.Sprintf("%d hours keep the items for one moon cycle; at most %d hours are allowed.",
+			models.MoonCycleHours, models.MaxLetGoExpiryHours),
+		body:   policy,
+		status: http.StatusNoContent,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	d.add(http.MethodDelete, "/profile/let-go-policy", operation{
+		summary: "Go back to the default let-go policy", tag: "profile", protected: true,
+		status: http.StatusNoContent,
+		errors: []int{http.StatusNotFound},
+	})
+}
+
+// entrySchemas adds the schemas of an entry type: the entry as answered,
+// the body of a new entry with the rules of the type and the body of an
+// update, in which every field is optional.
+func (d apiDocs) entrySchemas(entryType *models.EntryType) (entry, create, update *openapi.Schema) {
+	entry = d.doc.SchemaOf(entryType.New())
+	name := strings.TrimPrefix(entry.Ref, "#/components/schemas/")
+	schema := d.doc.Components.Schemas[name]
+
+	input := &openapi.Schema{Type: "object", Properties: make(map[string]*openapi.Schema)}
+	for field, property := range schema.Properties {
+		switch field {
+		case "id", "user_id", "created_at":
+			continue
+		}
+		copied := *property
+		input.Properties[field] = &copied
+	}
+	input.Properties["timezone"].Description = "IANA timezone of the writer, default " + models.DefaultTimezone
+	for _, field := range entryType.Derived {
+		input.Properties[field].Description = "Computed by the server; a value sent is replaced"
+	}
+	for _, rule := range entryType.Rules {
+		property := input.Properties[rule.Field]
+		if property == nil {
+			continue
+		}
+		if rule.Schema != nil {
+			*property = *ruleSchema(rule.Schema)
+		}
+		property.MaxLength = rule.MaxLength
+		property.Enum = rule.OneOf
+		if rule.Required {
+			input.Required = append(input.Required, rule.Field)
+		}
+	}
+
+	updateSchema := *input
+	updateSchema.Required = nil
+	d.doc.Components.Schemas["New"+name] = input
+	d.doc.Components.Schemas[name+"Update"] = &updateSchema
+
+	return entry, openapi.Ref("New" + name), openapi.Ref(name + "Update")
+}
+
+// ruleSchema converts the schema of a validation rule.
+func ruleSchema(s *models.Schema) *openapi.Schema {
+	schema := &openapi.Schema{Type: s.Type, MaxLength: s.MaxLength, MaxItems: s.MaxItems}
+	if s.Items != nil {
+		schema.Items = ruleSchema(s.Items)
+	}
+	return schema
+}
+
+// listParams are the query parameters of listFilter and pageRequest.
+func listParams(filters []string) []openapi.Parameter {
+	params := []openapi.Parameter{
+		query("from", "First day or timestamp, in tz", stringSchema("")),
+		query("to", "Last day or timestamp, in tz", stringSchema("")),
+		query("tz", "IANA timezone of from and to, default the timezone of the caller's latest entry or "+models.DefaultTimezone, stringSchema("")),
+		query("order", "Order by created_at, default desc", stringSchema("", "asc", "desc")),
+		query("limit", "Page size; answers a page instead of a plain list", intSchema(1, models.MaxPageSize)),
+		query("cursor", "next_cursor of the previous page", stringSchema("")),
+	}
+	for _, filter := range filters {
+		params = append(params, query(filter, "Only entries with this "+filter, stringSchema("")))
+	}
+	return params
+}
+
+// listResponse is a plain list of item, or a page of them when limit or
+// cursor is given.
+func listResponse(item *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{OneOf: []*openapi.Schema{
+		openapi.ArrayOf(item),
+		openapi.Object(map[string]*openapi.Schema{
+			"entries":     openapi.ArrayOf(item),
+			"next_cursor": {Type: "string", Nullable: true},
+		}),
+	}}
+}
+
+func (d apiDocs) entries() {
+	for _, entryType := range models.EntryTypes {
+		entry, create, update := d.entrySchemas(entryType)
+		path := "/" + entryType.Path
+		tag := entryType.Path
+
+		d.add(http.MethodGet, path, operation{
+			summary: "List " + entryType.Name + " entries", tag: tag, protected: true,
+			params:   listParams(entryType.Filters),
+			response: listResponse(entry),
+			errors:   []int{http.StatusBadRequest},
+		})
+		d.add(http.MethodPost, path, operation{
+			summary: "Write a " + entryType.Name, tag: tag, protected: true,
+			body:     create,
+			status:   http.StatusCreated,
+			response: entry,
+			errors:   []int{http.StatusBadRequest},
+		})
+		d.add(http.MethodGet, path+"/:id", operation{
+			summary: "Get a " + entryType.Name, tag: tag, protected: true,
+			response: entry,
+			errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+		})
+		d.add(http.MethodPatch, path+"/:id", operation{
+			summary: "Change the given fields of a " + entryType.Name, tag: tag, protected: true,
+			body:   update,
+			status: http.StatusNoContent,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		})
+		d.add(http.MethodDelete, path+"/:id", operation{
+			summary: "Delete a " + entryType.Name, tag: tag, protected: true,
+			status: http.StatusNoContent,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		})
+	}
+}
+
+// anyEntry is one of the entry schemas with the table it comes from.
+func anyEntry(doc *openapi.Document) *openapi.Schema {
+	var tables []string
+	var entries []*openapi.Schema
+	for _, entryType := range models.EntryTypes {
+		tables = append(tables, entryType.Table)
+		entries = append(entries, doc.SchemaOf(entryType.New()))
+	}
+
+	return &openapi.Schema{AllOf: []*openapi.Schema{
+		openapi.Object(map[string]*openapi.Schema{"type": stringSchema("", tables...)}),
+		{OneOf: entries},
+	}}
+}
+
+func (d apiDocs) reports() {
+	var tables []string
+	for _, entryType := range models.EntryTypes {
+		tables = append(tables, entryType.Table)
+	}
+	statsRange := []openapi.Parameter{
+		query("from", "First day, YYYY-MM-DD", stringSchema("date")),
+		query("to", "Last day, YYYY-MM-DD, default today", stringSchema("date")),
+		query("tz", "IANA timezone of the days, default the timezone of the caller's latest entry or "+models.DefaultTimezone, stringSchema("")),
+	}
+
+	d.add(http.MethodGet, "/search", operation{
+		summary: "Full-text search over all entries", tag: "entries", protected: true,
+		params: []openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Description: "Words to look for", Schema: stringSchema("")},
+			query("type", "Comma separated tables to search, default all of "+strings.Join(tables, ", "), stringSchema("")),
+			query("limit", fmt.Sprintf("Number of results, default %d", models.DefaultSearchLimit), intSchema(1, models.MaxPageSize)),
+		},
+		response: openapi.Object(map[string]*openapi.Schema{"results": openapi.ArrayOf(d.doc.SchemaOf(models.SearchResult{}))}),
+		errors:   []int{http.StatusBadRequest},
+	})
+	d.add(http.MethodGet, "/timeline", operation{
+		summary: "Entries of all types by created_at", tag: "entries", protected: true,
+		params:   listParams(nil),
+		response: listResponse(anyEntry(d.doc)),
+		errors:   []int{http.StatusBadRequest},
+	})
+	d.add(http.MethodGet, "/stats/moods", operation{
+		summary: "Distribution of emotion colors", tag: "stats", protected: true,
+		description: fmt.Sprintf("The range covers at most %d days by day, %d by week and %d by month.",
+			models.MaxStatsDays[models.PeriodDay], models.MaxStatsDays[models.PeriodWeek], models.MaxStatsDays[models.PeriodMonth]),
+		params: append(statsRange,
+			query("period", "Grouping, default day", stringSchema("", models.PeriodDay, models.PeriodWeek, models.PeriodMonth))),
+		response: d.doc.SchemaOf(models.MoodStats{}),
+		errors:   []int{http.StatusBadRequest},
+	})
+	d.add(http.MethodGet, "/stats/streaks", operation{
+		summary: "Journaling streaks and activity heatmap", tag: "stats", protected: true,
+		description: fmt.Sprintf("The heatmap covers at most %d days.", models.MaxHeatmapDays),
+		params:      statsRange,
+		response:    d.doc.SchemaOf(models.Streaks{}),
+		errors:      []int{http.StatusBadRequest},
+	})
+}
+
+func (d apiDocs) admin() {
+	d.add(http.MethodGet, "/admin/jobs", operation{
+		summary: "Status of the maintenance jobs", tag: "admin", protected: true,
+		response: openapi.Object(map[string]*openapi.Schema{"jobs": openapi.ArrayOf(d.doc.Component("JobStatus", jobs.Status{}))}),
+		errors:   []int{http.StatusForbidden},
+	})
+	d.add(http.MethodPost, "/admin/jobs/:name/run", operation{
+		summary: "Run a maintenance job now", tag: "admin", protected: true,
+		status:   http.StatusAccepted,
+		response: message,
+		errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	})
+}
+
+func (d apiDocs) legacy() {
+	var tables []string
+	var entries, creates, updates []*openapi.Schema
+	for _, entryType := range models.EntryTypes {
+		tables = append(tables, entryType.Table)
+		entry, create, update := d.entrySchemas(entryType)
+		entries = append(entries, entry)
+		creates = append(creates, create)
+		updates = append(updates, update)
+	}
+	table := openapi.Object(map[string]*openapi.Schema{"table": stringSchema("", tables...)})
+	id := &openapi.Schema{Type: "object", Required: []string{"id"}, Properties: map[string]*openapi.Schema{"id": {Type: "integer"}}}
+	indexes := make([]string, len(models.EntryTypes))
+	for i, entryType := range models.EntryTypes {
+		indexes[i] = fmt.Sprintf("%d: %s", entryType.Index, entryType.Table)
+	}
+	status := openapi.Object(map[string]*openapi.Schema{"status": {Type: "string"}})
+
+	d.add(http.MethodGet, "/entries", operation{
+		summary: "List entries of one type; use the typed resources", tag: "legacy", protected: true, deprecated: true,
+		params: append(listParams(nil),
+			query("selected_index", "Entry type, "+strings.Join(indexes, ", "), &openapi.Schema{Type: "integer"})),
+		response: listResponse(&openapi.Schema{OneOf: entries}),
+		errors:   []int{http.StatusBadRequest},
+	})
+	d.add(http.MethodPost, "/entries", operation{
+		summary: "Write an entry; use the typed resources", tag: "legacy", protected: true, deprecated: true,
+		body:     &openapi.Schema{AllOf: []*openapi.Schema{table, {OneOf: creates}}},
+		response: status,
+		errors:   []int{http.StatusBadRequest},
+	})
+	d.add(http.MethodPut, "/entries", operation{
+		summary: "Change an entry; use the typed resources", tag: "legacy", protected: true, deprecated: true,
+		body:     &openapi.Schema{AllOf: []*openapi.Schema{table, id, {OneOf: updates}}},
+		response: status,
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	d.add(http.MethodDelete, "/delete", operation{
+		summary: "Delete an entry; use the typed resources", tag: "legacy", protected: true, deprecated: true,
+		body:     d.doc.Component("DeleteRequest", DeleteRequest{}),
+		response: &openapi.Schema{Type: "string"},
+		errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+}
+
+// undocumentedRoutes returns the routes that have no operation in doc.
+func undocumentedRoutes(routes gin.RoutesInfo, doc *openapi.Document) []string {
+	var missing []string
+	for _, route := range routes {
+		if !doc.Has(route.Method, route.Path) {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// serveSpec answers GET /openapi.json with doc.
+func serveSpec(doc *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// docsUI renders /openapi.json with Swagger UI. The page is not
+// self-contained: the browser loads Swagger UI from unpkg.com.
+const docsUI = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Journal API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// serveDocsUI answers GET /docs with a page rendering the OpenAPI document.
+func serveDocsUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsUI))
+}
+
+// runOpenAPI implements the openapi subcommand: it prints the document and
+// fails if a route of newRouter is missing from it, which makes it usable
+// as a check in CI.
+func runOpenAPI() {
+	gin.SetMode(gin.ReleaseMode)
+	doc := apiSpec(cfg)
+
+	if missing := undocumentedRoutes(newRouter(cfg).Routes(), doc); len(missing) > 0 {
+		fmt.Fprintln(os.Stderr, "routes missing from the OpenAPI document:")
+		for _, route := range missing {
+			fmt.Fprintln(os.Stderr, "  "+route)
+		}
+		os.Exit(1)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,121 @@
+// Package apierror defines the errors the API answers with. Every error has
+// a stable code clients can program against, the HTTP status that goes with
+// it and a message that is safe to show. The underlying cause, such as a
+// GoTrue or PostgREST error, is only logged.
+package apierror
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Code identifies the kind of an error. Codes never change once published.
+type Code string
+
+const (
+	// InvalidRequest means the body or the parameters could not be parsed.
+	InvalidRequest Code = "invalid_request"
+	// ValidationFailed means a payload was parsed but some fields were
+	// rejected. Fields lists them.
+	ValidationFailed Code = "validation_failed"
+	// Unauthenticated means the access token is missing, invalid or expired.
+	Unauthenticated Code = "unauthenticated"
+	// InvalidCredentials means login or token refresh failed.
+	InvalidCredentials Code = "invalid_credentials"
+	// RegistrationFailed means the auth provider did not create the account.
+	RegistrationFailed Code = "registration_failed"
+	// Forbidden means the caller may not use the route.
+	Forbidden Code = "forbidden"
+	// NotFound means the route or the resource does not exist.
+	NotFound Code = "not_found"
+	// Conflict means the request clashes with the current state.
+	Conflict Code = "conflict"
+	// Internal means the server failed. The message tells nothing more.
+	Internal Code = "internal_error"
+	// Unavailable means a service the server depends on failed.
+	Unavailable Code = "upstream_unavailable"
+)
+
+// statuses holds every code with its HTTP status. Codes lists its keys, so
+// a new code must be added here to be documented.
+var statuses = map[Code]int{
+	InvalidRequest:     http.StatusBadRequest,
+	ValidationFailed:   http.StatusBadRequest,
+	Unauthenticated:    http.StatusUnauthorized,
+	InvalidCredentials: http.StatusUnauthorized,
+	RegistrationFailed: http.StatusBadRequest,
+	Forbidden:          http.StatusForbidden,
+	NotFound:           http.StatusNotFound,
+	Conflict:           http.StatusConflict,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusBadGateway,
+}
+
+// Codes returns every code the API answers with, sorted.
+func Codes() []Code {
+	codes := make([]Code, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Status returns the HTTP status of errors with code c.
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError tells why one field of a payload was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error the API answers with.
+type Error struct {
+	Code Code
+	// Message is shown to the client and must not contain the cause.
+	Message string
+	Fields  []FieldError
+	// Cause is logged but never shown to the client.
+	Cause error
+}
+
+// New returns an error with the given code and client message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap returns an error with the given code and client message caused by
+// cause.
+func Wrap(cause error, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Cause: cause}
+}
+
+// Validation returns a ValidationFailed error listing the rejected fields.
+func Validation(message string, fields []FieldError) *Error {
+	if fields == nil {
+		fields = []FieldError{}
+	}
+	return &Error{Code: ValidationFailed, Message: message, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return string(e.Code) + ": " + e.Message + ": " + e.Cause.Error()
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Status returns the HTTP status of the error.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
@@ -0,0 +1,81 @@
+package apierror
+
+import (
+	"errors"
+	"journal-backend/logging"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID of a request. Clients may send one; the
+// server answers with the ID it used.
+const RequestIDHeader = "X-Request-ID"
+
+// ctxRequestID is the key of the request ID in the gin.Context.
+const ctxRequestID = "request_id"
+
+// validRequestID limits the IDs taken over from clients, which end up in
+// the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Body is the JSON body of every error response.
+type Body struct {
+	// Error is the message, kept under this key for older clients.
+	Error     string       `json:"error"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// RequestID gives every request an ID, taken from the X-Request-ID header
+// if the client sent a usable one, and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set(ctxRequestID, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIDOf returns the ID RequestID gave the request.
+func RequestIDOf(c *gin.Context) string {
+	return c.GetString(ctxRequestID)
+}
+
+// Respond answers the request with err and stops the handler chain. Errors
+// that are not an *Error are answered as Internal. Causes are logged with
+// the request ID, never sent.
+func Respond(c *gin.Context, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Wrap(err, Internal, "Internal server error")
+	}
+
+	requestID := RequestIDOf(c)
+	status := apiErr.Status()
+	switch {
+	case status >= 500:
+		logging.Log.Errorf("[%s] %s %s: %v", requestID, c.Request.Method, c.Request.URL.Path, apiErr)
+	case apiErr.Cause != nil:
+		logging.Log.Debugf("[%s] %s %s: %v", requestID, c.Request.Method, c.Request.URL.Path, apiErr)
+	}
+
+	c.AbortWithStatusJSON(status, Body{
+		Error:     apiErr.Message,
+		Code:      apiErr.Code,
+		RequestID: requestID,
+		Fields:    apiErr.Fields,
+	})
+}
+
+// NoRoute answers requests for routes that do not exist.
+func NoRoute(c *gin.Context) {
+	Respond(c, New(NotFound, "Route not found"))
+}
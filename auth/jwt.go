@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// leeway tolerates small clock differences between GoTrue and this server.
+const leeway = 30 * time.Second
+
+var (
+	ErrMalformedToken = errors.New("malformed token")
+	ErrAlgorithm      = errors.New("unexpected signing algorithm")
+	ErrSignature      = errors.New("invalid token signature")
+	ErrExpired        = errors.New("token expired")
+	ErrNotYetValid    = errors.New("token not valid yet")
+	ErrAudience       = errors.New("invalid token audience")
+	ErrIssuer         = errors.New("invalid token issuer")
+	ErrSubject        = errors.New("invalid token subject")
+)
+
+// Claims are the parts of a Supabase access token the backend relies on.
+type Claims struct {
+	UserID    uuid.UUID
+	Role      string
+	Email     string
+	ExpiresAt time.Time
+}
+
+// Verifier checks Supabase access tokens locally, without asking GoTrue.
+type Verifier struct {
+	secret   []byte
+	audience string
+	issuer   string
+	now      func() time.Time
+}
+
+// NewVerifier creates a verifier for HS256 tokens signed with secret.
+// An empty audience or issuer disables the respective check.
+func NewVerifier(secret, audience, issuer string) (*Verifier, error) {
+	if secret == "" {
+		return nil, errors.New("jwt secret is required")
+	}
+
+	return &Verifier{
+		secret:   []byte(secret),
+		audience: audience,
+		issuer:   issuer,
+		now:      time.Now,
+	}, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type payload struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Email     string   `json:"email"`
+	Audience  audience `json:"aud"`
+	Issuer    string   `json:"iss"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience accepts both forms of the "aud" claim: a string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verify checks signature, expiry, audience and issuer of token and returns
+// its claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if h.Alg != "HS256" {
+		return nil, ErrAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrSignature
+	}
+
+	var p payload
+	if err := decodeSegment(parts[1], &p); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	now := v.now()
+	if p.ExpiresAt == nil {
+		return nil, ErrExpired
+	}
+	expiresAt := time.Unix(int64(*p.ExpiresAt), 0)
+	if now.After(expiresAt.Add(leeway)) {
+		return nil, ErrExpired
+	}
+	if p.NotBefore != nil && now.Add(leeway).Before(time.Unix(int64(*p.NotBefore), 0)) {
+		return nil, ErrNotYetValid
+	}
+	if v.audience != "" && !slices.Contains(p.Audience, v.audience) {
+		return nil, ErrAudience
+	}
+	if v.issuer != "" && strings.TrimSuffix(p.Issuer, "/") != strings.TrimSuffix(v.issuer, "/") {
+		return nil, ErrIssuer
+	}
+
+	userID, err := uuid.Parse(p.Subject)
+	if err != nil {
+		return nil, ErrSubject
+	}
+
+	return &Claims{
+		UserID:    userID,
+		Role:      p.Role,
+		Email:     p.Email,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret   = "secret"
+	testAudience = "authenticated"
+	testIssuer   = "https://project.supabase.co/auth/v1"
+	testSubject  = "11111111-1111-1111-1111-111111111111"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// sign builds a token with header and claims, signed with secret.
+func sign(t *testing.T, secret string, header, claims map[string]any) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	unsigned := segment(header) + "." + segment(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validClaims returns claims the test verifier accepts, changed by edit.
+func validClaims(edit func(map[string]any)) map[string]any {
+	claims := map[string]any{
+		"sub":   testSubject,
+		"role":  "authenticated",
+		"email": "user@example.com",
+		"aud":   testAudience,
+		"iss":   testIssuer,
+		"exp":   testNow.Add(time.Hour).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+
+	tests := []struct {
+		name   string
+		token  func(t *testing.T) string
+		issuer string
+		want   error
+	}{
+		{
+			name:  "valid",
+			token: func(t *testing.T) string { return sign(t, testSecret, hs256, validClaims(nil)) },
+		},
+		{
+			name:  "bad signature",
+			token: func(t *testing.T) string { return sign(t, "other", hs256, validClaims(nil)) },
+			want:  ErrSignature,
+		},
+		{
+			name: "alg none",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, map[string]any{"alg": "none"}, validClaims(nil))
+			},
+			want: ErrAlgorithm,
+		},
+		{
+			name: "alg HS512",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, map[string]any{"alg": "HS512"}, validClaims(nil))
+			},
+			want: ErrAlgorithm,
+		},
+		{
+			name:  "malformed",
+			token: func(t *testing.T) string { return "not.a-token" },
+			want:  ErrMalformedToken,
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["exp"] = testNow.Add(-time.Minute).Unix()
+				}))
+			},
+			want: ErrExpired,
+		},
+		{
+			name: "expired within leeway",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["exp"] = testNow.Add(-10 * time.Second).Unix()
+				}))
+			},
+		},
+		{
+			name: "no exp",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) { delete(c, "exp") }))
+			},
+			want: ErrExpired,
+		},
+		{
+			name: "nbf in the future",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["nbf"] = testNow.Add(time.Minute).Unix()
+				}))
+			},
+			want: ErrNotYetValid,
+		},
+		{
+			name: "nbf in the past",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["nbf"] = testNow.Add(-time.Minute).Unix()
+				}))
+			},
+		},
+		{
+			name: "audience list",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["aud"] = []string{"other", testAudience}
+				}))
+			},
+		},
+		{
+			name: "wrong audience",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) { c["aud"] = "other" }))
+			},
+			want: ErrAudience,
+		},
+		{
+			name: "wrong audience list",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["aud"] = []string{"other"}
+				}))
+			},
+			want: ErrAudience,
+		},
+		{
+			name: "issuer with trailing slash",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) { c["iss"] = testIssuer + "/" }))
+			},
+		},
+		{
+			name:   "configured issuer with trailing slash",
+			token:  func(t *testing.T) string { return sign(t, testSecret, hs256, validClaims(nil)) },
+			issuer: testIssuer + "/",
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) {
+					c["iss"] = "https://other.supabase.co/auth/v1"
+				}))
+			},
+			want: ErrIssuer,
+		},
+		{
+			name: "non-UUID sub",
+			token: func(t *testing.T) string {
+				return sign(t, testSecret, hs256, validClaims(func(c map[string]any) { c["sub"] = "user-1" }))
+			},
+			want: ErrSubject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := tt.issuer
+			if issuer == "" {
+				issuer = testIssuer
+			}
+			v, err := NewVerifier(testSecret, testAudience, issuer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.now = func() time.Time { return testNow }
+
+			claims, err := v.Verify(tt.token(t))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+			if claims.UserID.String() != testSubject {
+				t.Errorf("UserID = %s, want %s", claims.UserID, testSubject)
+			}
+			if claims.Role != "authenticated" || claims.Email != "user@example.com" {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package config loads the server configuration once at startup. Values
+// come from, in increasing precedence: the defaults, an optional YAML file,
+// an optional .env file and the environment. The .env file never overrides
+// variables that are already set.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"journal-backend/db"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read if CONFIG_FILE is not set and the file exists.
+const DefaultFile = "config.yaml"
+
+// Config is the configuration of the server. Every field can be set in the
+// YAML file under its yaml key and in the environment under its env name.
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Supabase Supabase `yaml:"supabase"`
+	Storage  Storage  `yaml:"storage"`
+	Log      Log      `yaml:"log"`
+	Jobs     Jobs     `yaml:"jobs"`
+}
+
+type Server struct {
+	// Address is passed to gin's Run. If empty, gin listens on $PORT or
+	// :8080.
+	Address string `yaml:"address" env:"SERVER_URL"`
+	// DocsUI serves a page at /docs that renders the OpenAPI document
+	// with Swagger UI loaded from unpkg.com.
+	DocsUI bool `yaml:"docs_ui" env:"API_DOCS_UI"`
+	// AdminRole is the token role required for the admin routes.
+	AdminRole string `yaml:"admin_role" env:"ADMIN_ROLE"`
+}
+
+type Supabase struct {
+	URL string `yaml:"url" env:"SUPABASE_URL"`
+	// Key is the anon key used for auth requests.
+	Key string `yaml:"key" env:"SUPABASE_KEY"`
+	// ServiceRoleKey lets the maintenance jobs see the data of all users.
+	// Without it let-go expiry is disabled on Supabase.
+	ServiceRoleKey string `yaml:"service_role_key" env:"SUPABASE_SERVICE_ROLE_KEY"`
+	JWTSecret      string `yaml:"jwt_secret" env:"SUPABASE_JWT_SECRET"`
+	JWTAudience    string `yaml:"jwt_audience" env:"SUPABASE_JWT_AUDIENCE"`
+	// JWTIssuer defaults to the auth URL of the project.
+	JWTIssuer string `yaml:"jwt_issuer" env:"SUPABASE_JWT_ISSUER"`
+}
+
+// AuthEnabled reports whether Supabase Auth can be used for registration,
+// sign in and sign out. Only the memory backend runs without it; requests
+// then carry tokens signed with JWTSecret, e.g. by a local script.
+func (s Supabase) AuthEnabled() bool {
+	return s.URL != "" && s.Key != ""
+}
+
+// Backends of Storage.
+const (
+	BackendSupabase = "supabase"
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+type Storage struct {
+	Backend     string `yaml:"backend" env:"STORAGE_BACKEND"`
+	DatabaseURL string `yaml:"database_url" env:"DATABASE_URL"`
+}
+
+// Formats of Log.
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+type Jobs struct {
+	LetGoExpirySchedule string `yaml:"let_go_expiry_schedule" env:"LET_GO_EXPIRY_SCHEDULE"`
+}
+
+// Default returns the configuration used for everything not set.
+func Default() Config {
+	return Config{
+		Server:   Server{AdminRole: "admin"},
+		Supabase: Supabase{JWTAudience: "authenticated"},
+		Storage:  Storage{Backend: BackendSupabase},
+		Log:      Log{Level: "debug", Format: LogText},
+		Jobs:     Jobs{LetGoExpirySchedule: "0 * * * *"},
+	}
+}
+
+// Load reads the configuration. A missing .env file or default YAML file
+// is fine; a file named by CONFIG_FILE must exist. The result is not
+// validated, see Validate.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	cfg := Default()
+
+	path, named := os.LookupEnv("CONFIG_FILE")
+	if !named {
+		path = DefaultFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	case !named && errors.Is(err, os.ErrNotExist):
+	default:
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	if cfg.Supabase.JWTIssuer == "" && cfg.Supabase.URL != "" {
+		cfg.Supabase.JWTIssuer = cfg.Supabase.URL + db.AUTH_URL
+	}
+
+	return &cfg, nil
+}
+
+// applyEnv sets the fields with an env tag whose variable is set.
+func applyEnv(v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, info := v.Field(i), v.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := info.Tag.Get("env")
+		value, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: expected true or false, got %q", name, value)
+			}
+			field.SetBool(b)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"journal-backend/jobs"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidationError lists everything wrong with a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks that the server can start with c. Problems name the
+// environment variable and the YAML key of the setting.
+func (c *Config) Validate() error {
+	var problems []string
+	missing := func(env, key string) {
+		problems = append(problems, fmt.Sprintf("%s (%s) is required", env, key))
+	}
+
+	// The memory backend runs without outside services, see
+	// Supabase.AuthEnabled.
+	if c.Storage.Backend != BackendMemory {
+		if c.Supabase.URL == "" {
+			missing("SUPABASE_URL", "supabase.url")
+		}
+		if c.Supabase.Key == "" {
+			missing("SUPABASE_KEY", "supabase.key")
+		}
+	}
+	if c.Supabase.JWTSecret == "" {
+		missing("SUPABASE_JWT_SECRET", "supabase.jwt_secret")
+	}
+	if c.Server.AdminRole == "" {
+		missing("ADMIN_ROLE", "server.admin_role")
+	}
+
+	switch c.Storage.Backend {
+	case BackendSupabase, BackendMemory:
+	case BackendPostgres:
+		if c.Storage.DatabaseURL == "" {
+			problems = append(problems, "DATABASE_URL (storage.database_url) is required for the postgres backend")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("STORAGE_BACKEND (storage.backend) must be %s, %s or %s, got %q",
+			BackendSupabase, BackendMemory, BackendPostgres, c.Storage.Backend))
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL (log.level) is not a log level: %q", c.Log.Level))
+	}
+	if c.Log.Format != LogText && c.Log.Format != LogJSON {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT (log.format) must be %s or %s, got %q", LogText, LogJSON, c.Log.Format))
+	}
+
+	if _, err := jobs.ParseSchedule(c.Jobs.LetGoExpirySchedule); err != nil {
+		problems = append(problems, fmt.Sprintf("LET_GO_EXPIRY_SCHEDULE (jobs.let_go_expiry_schedule): %v", err))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+var ErrNoSession = errors.New("no session for access token")
+
+type session struct {
+	client    *Client
+	expiresAt time.Time
+}
+
+// Sessions keeps one authenticated Client per access token, so that every
+// request talks to Supabase with the identity of its own caller.
+type Sessions struct {
+	mu      sync.RWMutex
+	url     string
+	key     string
+	clients map[string]session
+}
+
+// NewSessions creates an empty session store.
+// url and key are used to build clients for tokens that are not cached yet.
+func NewSessions(url, key string) *Sessions {
+	return &Sessions{
+		url:     url,
+		key:     key,
+		clients: make(map[string]session),
+	}
+}
+
+// Put stores the client that was signed in with the given session.
+func (s *Sessions) Put(auth types.Session, client *Client) {
+	expiresAt := time.Unix(auth.ExpiresAt, 0)
+	if auth.ExpiresAt == 0 {
+		expiresAt = time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired()
+	s.clients[auth.AccessToken] = session{client: client, expiresAt: expiresAt}
+}
+
+// Get returns the client belonging to accessToken. The token must already be
+// verified; userID and expiresAt are taken from its claims.
+func (s *Sessions) Get(accessToken string, userID uuid.UUID, expiresAt time.Time) (*Client, error) {
+	if accessToken == "" || userID == uuid.Nil {
+		return nil, ErrNoSession
+	}
+
+	s.mu.RLock()
+	cached, ok := s.clients[accessToken]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.client, nil
+	}
+
+	client, err := NewClient(s.url, s.key, nil)
+	if err != nil {
+		return nil, err
+	}
+	client.UpdateAuthSession(types.Session{AccessToken: accessToken})
+	client.UserID = userID
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired()
+	s.clients[accessToken] = session{client: client, expiresAt: expiresAt}
+
+	return client, nil
+}
+
+// Remove forgets the client stored for accessToken.
+func (s *Sessions) Remove(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients, accessToken)
+}
+
+// evictExpired drops all expired sessions. The caller must hold the lock.
+func (s *Sessions) evictExpired() {
+	now := time.Now()
+	for token, cached := range s.clients {
+		if now.After(cached.expiresAt) {
+			delete(s.clients, token)
+		}
+	}
+}
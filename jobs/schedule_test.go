@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseField(t *testing.T) {
+	bits := func(values ...int) uint64 {
+		var b uint64
+		for _, v := range values {
+			b |= 1 << uint(v)
+		}
+		return b
+	}
+
+	tests := []struct {
+		field    string
+		min, max int
+		want     uint64
+		wantErr  bool
+	}{
+		{field: "*", min: 0, max: 5, want: bits(0, 1, 2, 3, 4, 5)},
+		{field: "3", min: 0, max: 59, want: bits(3)},
+		{field: "1,3,5", min: 0, max: 59, want: bits(1, 3, 5)},
+		{field: "2-4", min: 0, max: 59, want: bits(2, 3, 4)},
+		{field: "*/15", min: 0, max: 59, want: bits(0, 15, 30, 45)},
+		{field: "10-20/5", min: 0, max: 59, want: bits(10, 15, 20)},
+		{field: "50/5", min: 0, max: 59, want: bits(50, 55)},
+		{field: "1-2,*/10", min: 0, max: 30, want: bits(0, 1, 2, 10, 20, 30)},
+		{field: "7", min: 0, max: 7, want: bits(7)},
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "5-3", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "*/x", min: 0, max: 59, wantErr: true},
+		{field: "a", min: 0, max: 59, wantErr: true},
+		{field: "1-b", min: 0, max: 59, wantErr: true},
+		{field: "", min: 0, max: 59, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseField(tt.field, tt.min, tt.max)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseField(%q) = %b, want an error", tt.field, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseField(%q) = %b, %v, want %b", tt.field, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "@every 10ms", "@every soon"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2024-05-01 is a Wednesday.
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{spec: "* * * * *", from: "2024-05-01 10:00", want: "2024-05-01 10:01"},
+		{spec: "*/15 * * * *", from: "2024-05-01 10:07", want: "2024-05-01 10:15"},
+		{spec: "*/15 * * * *", from: "2024-05-01 10:45", want: "2024-05-01 11:00"},
+		{spec: "0 * * * *", from: "2024-05-01 10:00", want: "2024-05-01 11:00"},
+		{spec: "10-20/5 8 * * *", from: "2024-05-01 08:16", want: "2024-05-01 08:20"},
+		{spec: "10-20/5 8 * * *", from: "2024-05-01 08:20", want: "2024-05-02 08:10"},
+		{spec: "30 9-17/4 * * *", from: "2024-05-01 13:30", want: "2024-05-01 17:30"},
+		{spec: "0 0 1 * *", from: "2024-05-01 00:00", want: "2024-06-01 00:00"},
+		{spec: "0 0 31 * *", from: "2024-05-31 12:00", want: "2024-07-31 00:00"},
+		{spec: "0 0 29 2 *", from: "2024-03-01 00:00", want: "2028-02-29 00:00"},
+		{spec: "0 0 * 1,7 *", from: "2024-05-01 00:00", want: "2024-07-01 00:00"},
+		// Restricted day of month and day of week: either one matches.
+		{spec: "0 0 13 * 5", from: "2024-05-01 00:00", want: "2024-05-03 00:00"},
+		{spec: "0 0 2 * 5", from: "2024-05-01 00:00", want: "2024-05-02 00:00"},
+		// With one day field *, only the other restricts the day.
+		{spec: "0 0 * * 5", from: "2024-05-01 00:00", want: "2024-05-03 00:00"},
+		{spec: "0 0 13 * *", from: "2024-05-01 00:00", want: "2024-05-13 00:00"},
+		// Sunday is 0 or 7.
+		{spec: "0 12 * * 0", from: "2024-05-01 00:00", want: "2024-05-05 12:00"},
+		{spec: "0 12 * * 7", from: "2024-05-01 00:00", want: "2024-05-05 12:00"},
+		{spec: "0 12 * * 5-7", from: "2024-05-01 00:00", want: "2024-05-03 12:00"},
+		{spec: "@daily", from: "2024-05-01 10:00", want: "2024-05-02 00:00"},
+		{spec: "@weekly", from: "2024-05-01 10:00", want: "2024-05-05 00:00"},
+		{spec: "@every 90m", from: "2024-05-01 10:00", want: "2024-05-01 11:30"},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q: Next(%s) = %s, want %s", tt.spec, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next() = %s, want the zero time", got)
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	kolkata := time.FixedZone("IST", 5*3600+30*60)
+	kathmandu := time.FixedZone("NPT", 5*3600+45*60)
+	stJohns := time.FixedZone("NST", -(3*3600 + 30*60))
+
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{spec: "0 3 * * *", from: time.Date(2024, 5, 1, 10, 0, 0, 0, kolkata), want: time.Date(2024, 5, 2, 3, 0, 0, 0, kolkata)},
+		{spec: "0 3 * * *", from: time.Date(2024, 5, 1, 1, 59, 30, 0, kolkata), want: time.Date(2024, 5, 1, 3, 0, 0, 0, kolkata)},
+		{spec: "15 * * * *", from: time.Date(2024, 5, 1, 10, 20, 0, 0, kathmandu), want: time.Date(2024, 5, 1, 11, 15, 0, 0, kathmandu)},
+		{spec: "0 9 * * 1", from: time.Date(2024, 5, 1, 10, 0, 0, 0, stJohns), want: time.Date(2024, 5, 6, 9, 0, 0, 0, stJohns)},
+		// 02:30 does not exist on the day clocks move forward.
+		{spec: "30 2 * * *", from: time.Date(2024, 3, 31, 0, 0, 0, 0, berlin), want: time.Date(2024, 4, 1, 2, 30, 0, 0, berlin)},
+		{spec: "0 4 * * *", from: time.Date(2024, 3, 31, 0, 0, 0, 0, berlin), want: time.Date(2024, 3, 31, 4, 0, 0, 0, berlin)},
+		{spec: "0 4 * * *", from: time.Date(2024, 10, 27, 0, 0, 0, 0, berlin), want: time.Date(2024, 10, 27, 4, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next(%s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
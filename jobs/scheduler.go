@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journal-backend/logging"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Results of a job run.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Func is the work of a job. ctx is cancelled when the scheduler stops.
+type Func func(ctx context.Context) error
+
+// Status describes a job and its last run.
+type Status struct {
+	Name       string     `json:"name"`
+	Schedule   string     `json:"schedule"`
+	Running    bool       `json:"running"`
+	NextRun    *time.Time `json:"next_run"`
+	LastStart  *time.Time `json:"last_started_at"`
+	LastEnd    *time.Time `json:"last_finished_at"`
+	LastResult string     `json:"last_result"`
+	LastError  string     `json:"last_error,omitempty"`
+	Runs       int        `json:"runs"`
+	Failures   int        `json:"failures"`
+	// Skipped counts the runs that were due while the previous one was
+	// still running.
+	Skipped int `json:"skipped"`
+}
+
+type job struct {
+	schedule Schedule
+	run      Func
+	status   Status
+}
+
+// Scheduler runs jobs in the background of the server. A job never runs
+// twice at the same time; a run that is due while the previous one has not
+// finished is skipped.
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []*job
+	ctx    context.Context
+	cancel context.CancelFunc
+	runs   sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler without jobs.
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{ctx: ctx, cancel: cancel}
+}
+
+// Add registers run under name with a schedule in the format of
+// ParseSchedule. Jobs added after Start are not scheduled.
+func (s *Scheduler) Add(name, spec string, run Func) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.status.Name == name {
+			return fmt.Errorf("job %q is already registered", name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{
+		schedule: schedule,
+		run:      run,
+		status:   Status{Name: name, Schedule: spec},
+	})
+
+	return nil
+}
+
+// Start schedules all registered jobs.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		go s.loop(j)
+	}
+}
+
+// Stop cancels running jobs, waits for them to return and schedules no
+// further runs.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.runs.Wait()
+}
+
+// Run starts the job called name now, in the background.
+func (s *Scheduler) Run(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.status.Name == name {
+			if !s.startLocked(j) {
+				return ErrJobRunning
+			}
+			return nil
+		}
+	}
+	return ErrUnknownJob
+}
+
+// Status returns the status of all jobs in the order they were added.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, len(s.jobs))
+	for i, j := range s.jobs {
+		statuses[i] = j.status
+	}
+	return statuses
+}
+
+func (s *Scheduler) loop(j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			logging.Log.Warnf("Job %s has no next run, it will not be scheduled again", j.status.Name)
+			return
+		}
+
+		s.mu.Lock()
+		j.status.NextRun = &next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if !s.startLocked(j) {
+			j.status.Skipped++
+			logging.Log.Warnf("Skipping job %s, the previous run has not finished", j.status.Name)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// startLocked starts a run of j unless one is in progress. s.mu must be held.
+func (s *Scheduler) startLocked(j *job) bool {
+	if j.status.Running || s.ctx.Err() != nil {
+		return false
+	}
+
+	started := time.Now().UTC()
+	j.status.Running = true
+	j.status.LastStart = &started
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		err := s.execute(j)
+
+		finished := time.Now().UTC()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		j.status.Running = false
+		j.status.LastEnd = &finished
+		j.status.Runs++
+		if err != nil {
+			j.status.LastResult = ResultFailure
+			j.status.LastError = err.Error()
+			j.status.Failures++
+			logging.Log.Errorf("Job %s failed after %s: %v", j.status.Name, finished.Sub(started), err)
+			return
+		}
+		j.status.LastResult = ResultSuccess
+		j.status.LastError = ""
+		logging.Log.Infof("Job %s finished after %s", j.status.Name, finished.Sub(started))
+	}()
+
+	return true
+}
+
+// execute runs j and turns a panic into an error, so one broken job does not
+// take the server down.
+func (s *Scheduler) execute(j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return j.run(s.ctx)
+}
@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// tick is a schedule that is due every d, shorter than ParseSchedule allows.
+type tick time.Duration
+
+func (d tick) Next(t time.Time) time.Time { return t.Add(time.Duration(d)) }
+
+// addJob registers run with schedule, bypassing ParseSchedule.
+func addJob(s *Scheduler, name string, schedule Schedule, run Func) {
+	s.jobs = append(s.jobs, &job{schedule: schedule, run: run, status: Status{Name: name}})
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	s := NewScheduler()
+	release := make(chan struct{})
+	addJob(s, "slow", tick(10*time.Millisecond), func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	s.Start()
+	waitFor(t, func() bool { return s.Status()[0].Skipped >= 3 })
+
+	status := s.Status()[0]
+	if !status.Running || status.Runs != 0 {
+		t.Errorf("status while blocked = %+v, want one running and none finished", status)
+	}
+	if err := s.Run("slow"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Run() while running = %v, want ErrJobRunning", err)
+	}
+
+	close(release)
+	waitFor(t, func() bool { return s.Status()[0].Runs >= 2 })
+	s.Stop()
+
+	status = s.Status()[0]
+	if status.Running || status.LastResult != ResultSuccess || status.Failures != 0 {
+		t.Errorf("status after stop = %+v", status)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	s := NewScheduler()
+	addJob(s, "failing", tick(time.Hour), func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	addJob(s, "panicking", tick(time.Hour), func(ctx context.Context) error {
+		panic("boom")
+	})
+	defer s.Stop()
+
+	if err := s.Run("missing"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("Run(missing) = %v, want ErrUnknownJob", err)
+	}
+	for _, name := range []string{"failing", "panicking"} {
+		if err := s.Run(name); err != nil {
+			t.Fatalf("Run(%s): %v", name, err)
+		}
+	}
+	waitFor(t, func() bool {
+		statuses := s.Status()
+		return statuses[0].Runs == 1 && statuses[1].Runs == 1
+	})
+
+	for _, status := range s.Status() {
+		if status.LastResult != ResultFailure || status.Failures != 1 || status.LastError == "" {
+			t.Errorf("status of %s = %+v, want one failure", status.Name, status)
+		}
+	}
+}
+
+func TestSchedulerStopCancelsRuns(t *testing.T) {
+	s := NewScheduler()
+	cancelled := make(chan struct{})
+	addJob(s, "waiting", tick(time.Hour), func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	if err := s.Run("waiting"); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("Stop returned before the run was cancelled")
+	}
+	if status := s.Status()[0]; status.Running || status.Runs != 1 {
+		t.Errorf("status after Stop = %+v, want one finished run", status)
+	}
+}
+
+func TestSchedulerAddRejectsDuplicates(t *testing.T) {
+	s := NewScheduler()
+	run := func(ctx context.Context) error { return nil }
+	if err := s.Add("job", "@hourly", run); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("job", "@daily", run); err == nil {
+		t.Error("Add() of a duplicate name succeeded")
+	}
+	if err := s.Add("other", "bad", run); err == nil {
+		t.Error("Add() of an invalid schedule succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"journal-backend/apierror"
+	"journal-backend/models"
+	"journal-backend/store"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getLetGoPolicy answers GET /profile/let-go-policy with how long the
+// caller's let-go items are kept.
+func getLetGoPolicy(c *gin.Context) {
+	policy, err := models.GetLetGoPolicy(userStore(c), currentUserID(c))
+	if errors.Is(err, store.ErrNotFound) {
+		apierror.Respond(c, apierror.New(apierror.NotFound, "Profile not found"))
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to fetch let-go policy"))
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// putLetGoPolicy answers PUT /profile/let-go-policy with a body like
+// {"expire_after_hours": 709, "action": "archive"}.
+func putLetGoPolicy(c *gin.Context) {
+	var policy models.LetGoPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
+		return
+	}
+
+	err := models.SetLetGoPolicy(userStore(c), currentUserID(c), policy)
+	switch {
+	case errors.Is(err, models.ErrInvalidPolicy):
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, err.Error()))
+	case errors.Is(err, store.ErrNotFound):
+		apierror.Respond(c, apierror.New(apierror.NotFound, "Profile not found"))
+	case err != nil:
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to save let-go policy"))
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// deleteLetGoPolicy answers DELETE /profile/let-go-policy by going back to
+// the default policy.
+func deleteLetGoPolicy(c *gin.Context) {
+	err := models.ResetLetGoPolicy(userStore(c), currentUserID(c))
+	if errors.Is(err, store.ErrNotFound) {
+		apierror.Respond(c, apierror.New(apierror.NotFound, "Profile not found"))
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to reset let-go policy"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -15,3 +15,17 @@ var Log = &logrus.Logger{
 		LogFormat:       "%time% [%lvl%] %msg% \n",
 	},
 }
+
+// Configure sets the level of Log, e.g. "info", and switches to JSON lines
+// if json is set.
+func Configure(level string, json bool) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	Log.SetLevel(lvl)
+	if json {
+		Log.SetFormatter(&logrus.JSONFormatter{TimestampFormat: "2006-01-02T15:04:05Z07:00"})
+	}
+	return nil
+}
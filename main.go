@@ -1,23 +1,47 @@
 package main
 
 import (
-	"encoding/json"
+	"journal-backend/apierror"
+	"journal-backend/auth"
+	"journal-backend/config"
 	"journal-backend/db"
-	"journal-backend/helpers"
 	"journal-backend/logging"
 	"journal-backend/models"
+	"journal-backend/store"
 	"net/http"
 	"os"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/supabase-community/gotrue-go/types"
 )
 
-var globalClient *db.Client
+// cfg is the configuration loaded at startup.
+var cfg *config.Config
+
+// sessions maps the bearer token of every request to the db.Client of
+// the user it belongs to.
+var sessions *db.Sessions
+
+// verifier checks the access tokens of protected routes locally.
+var verifier *auth.Verifier
+
+// sharedStore is used by all requests when the configured backend does not
+// work with the Supabase session of the caller. It is nil for Supabase.
+var sharedStore store.Store
+
+// streaks keeps the journaling streaks of all users up to date.
+var streaks *models.StreakService
+
+// Keys under which authMiddleware stores the caller in the gin.Context.
+const (
+	ctxUserID = "user_id"
+	ctxRole   = "role"
+	ctxClient = "db_client"
+	ctxStore  = "store"
+)
 
 type LoginRequest struct {
 	Email    string `json:"email"`
@@ -30,9 +54,13 @@ type RegisterRequest struct {
 	Username string `json:"username"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type DeleteRequest struct {
 	Table string `json:"table"`
-	Id    int8   `json:"id"`
+	Id    int    `json:"id"`
 }
 
 type InsertEntry struct {
@@ -40,64 +68,234 @@ type InsertEntry struct {
 }
 
 func main() {
-	err := godotenv.Load()
+	var err error
+	cfg, err = config.Load()
+	if err != nil {
+		logging.Log.Fatal("Error loading configuration: ", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		runOpenAPI()
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		logging.Log.Fatal(err)
+	}
+	if err := logging.Configure(cfg.Log.Level, cfg.Log.Format == config.LogJSON); err != nil {
+		logging.Log.Fatal("Error configuring logging: ", err)
+	}
+
+	sessions = db.NewSessions(cfg.Supabase.URL, cfg.Supabase.Key)
+
+	verifier, err = auth.NewVerifier(cfg.Supabase.JWTSecret, cfg.Supabase.JWTAudience, cfg.Supabase.JWTIssuer)
 	if err != nil {
-		logging.Log.Fatal("Error loading .env-File")
+		logging.Log.Fatal("Error configuring token verification: ", err)
+	}
+
+	switch cfg.Storage.Backend {
+	case config.BackendSupabase:
+	case config.BackendMemory:
+		logging.Log.Warn("Using in-memory storage, data is lost on restart")
+		sharedStore = store.NewMemory()
+	case config.BackendPostgres:
+		postgres, err := store.NewPostgres(cfg.Storage.DatabaseURL)
+		if err != nil {
+			logging.Log.Fatal("Error connecting to postgres: ", err)
+		}
+		defer postgres.Close()
+		sharedStore = postgres
 	}
 
+	streaks = models.NewStreakService()
+
+	startJobs()
+	defer scheduler.Stop()
+
 	logging.Log.Info("Connecting to API...")
+	newRouter(cfg).Run(cfg.Server.Address)
+}
+
+// newRouter registers all routes. Every route needs an operation in
+// apiSpec, which main_test.go checks.
+func newRouter(cfg *config.Config) *gin.Engine {
 	router := gin.Default()
-	router.GET("/profiles", getAllUsers)
-	router.POST("/register", signUpWithEmailPassword)
-	router.POST("/login", signInWithEmailPassword)
-	router.POST("/logout", logoutUser)
-	router.GET("/entries", getEntries)
-	router.POST("/entries", newEntry)
-	router.PUT("/entries", updateEntry)
-	router.DELETE("/delete", deleteEntry)
+	router.Use(apierror.RequestID())
+	router.NoRoute(apierror.NoRoute)
+	router.GET("/openapi.json", serveSpec(apiSpec(cfg)))
+	if cfg.Server.DocsUI {
+		router.GET("/docs", serveDocsUI)
+	}
+	router.POST("/register", requireAuthService(), signUpWithEmailPassword)
+	router.POST("/login", requireAuthService(), signInWithEmailPassword)
+	router.POST("/token/refresh", requireAuthService(), refreshSession)
+	router.GET("/moon/calendar", getMoonCalendar)
+	router.GET("/moon/phase", getMoonPhase)
+
+	protected := router.Group("/", authMiddleware())
+	protected.GET("/profiles", getAllUsers)
+	protected.GET("/profile/let-go-policy", getLetGoPolicy)
+	protected.PUT("/profile/let-go-policy", putLetGoPolicy)
+	protected.DELETE("/profile/let-go-policy", deleteLetGoPolicy)
+	protected.POST("/logout", logoutUser)
+	registerEntryResources(protected)
+	protected.GET("/search", searchEntries)
+	protected.GET("/timeline", getTimeline)
+	protected.GET("/stats/moods", getMoodStats)
+	protected.GET("/stats/streaks", getStreaks)
+
+	admin := protected.Group("/admin", requireRole(cfg.Server.AdminRole))
+	admin.GET("/jobs", listJobs)
+	admin.POST("/jobs/:name/run", runJob)
+
+	// Deprecated: replaced by the routes of registerEntryResources.
+	legacy := protected.Group("/", deprecated())
+	legacy.GET("/entries", getEntries)
+	legacy.POST("/entries", newEntry)
+	legacy.PUT("/entries", updateEntry)
+	legacy.DELETE("/delete", deleteEntry)
 
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Next()
 	})
 
-	router.Run(os.Getenv("SERVER_URL"))
+	return router
+}
+
+// authMiddleware verifies the bearer access token locally and stores the
+// caller's UUID, role, db.Client and store in the gin.Context.
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			apierror.Respond(c, apierror.New(apierror.Unauthenticated, "Missing bearer token"))
+			return
+		}
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Unauthenticated, "Invalid access token"))
+			return
+		}
+
+		c.Set(ctxUserID, claims.UserID)
+		c.Set(ctxRole, claims.Role)
+
+		if sharedStore != nil {
+			c.Set(ctxStore, sharedStore)
+			c.Next()
+			return
+		}
+
+		dbClient, err := sessions.Get(token, claims.UserID, claims.ExpiresAt)
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error client initializing"))
+			return
+		}
+
+		c.Set(ctxClient, dbClient)
+		c.Set(ctxStore, storeFor(dbClient))
+		c.Next()
+	}
+}
+
+// requireAuthService answers requests that need Supabase Auth when it is
+// not configured.
+func requireAuthService() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Supabase.AuthEnabled() {
+			apierror.Respond(c, apierror.New(apierror.Unavailable, "Authentication is not configured"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// authClient returns a client acting with the caller's access token.
+// authMiddleware only sets one for the Supabase backend.
+func authClient(c *gin.Context) (*db.Client, error) {
+	if value, ok := c.Get(ctxClient); ok {
+		return value.(*db.Client), nil
+	}
+
+	dbClient, err := db.NewClient(cfg.Supabase.URL, cfg.Supabase.Key, nil)
+	if err != nil {
+		return nil, err
+	}
+	dbClient.UpdateAuthSession(types.Session{AccessToken: bearerToken(c)})
+	dbClient.UserID = c.MustGet(ctxUserID).(uuid.UUID)
+	return dbClient, nil
+}
+
+// currentUserID returns the UUID of the caller stored by authMiddleware.
+func currentUserID(c *gin.Context) string {
+	return c.MustGet(ctxUserID).(uuid.UUID).String()
+}
+
+// bearerToken extracts the access token from the Authorization header.
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// storeFor returns the store to use with the session of dbClient.
+func storeFor(dbClient *db.Client) store.Store {
+	if sharedStore != nil {
+		return sharedStore
+	}
+	return store.NewSupabase(dbClient)
+}
+
+// userStore returns the store the caller's request works on.
+func userStore(c *gin.Context) store.Store {
+	return c.MustGet(ctxStore).(store.Store)
 }
 
 func signUpWithEmailPassword(c *gin.Context) {
 	var req RegisterRequest
 
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request body"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
 		return
 	}
 
-	url := os.Getenv("SUPABASE_URL")
-	apiKey := os.Getenv("SUPABASE_KEY")
-
-	dbClient, err := db.NewClient(url, apiKey, nil)
+	dbClient, err := db.NewClient(cfg.Supabase.URL, cfg.Supabase.Key, nil)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Error client initializing"})
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error client initializing"))
 		return
 	}
 
 	user, err := dbClient.SignUpWithEmailPassword(req.Email, req.Password)
 
 	if err != nil {
-		c.JSON(401, gin.H{"error": err.Error()})
+		apierror.Respond(c, apierror.Wrap(err, apierror.RegistrationFailed, "Registration failed"))
 		return
 	}
 
-	token, _ := dbClient.SignInWithEmailPassword(req.Email, req.Password)
+	token, err := dbClient.SignInWithEmailPassword(req.Email, req.Password)
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Unavailable, "Registered, but signing in failed"))
+		return
+	}
 
 	newUser := models.User{
 		UserId: token.User.ID.String(),
 		Name:   req.Username,
 	}
 
-	err = models.NewUser(*dbClient, newUser)
+	err = models.NewUser(storeFor(dbClient), newUser)
 	if err != nil {
-		c.JSON(401, gin.H{"error": err.Error()})
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to create profile"))
 		return
 	}
 
@@ -111,54 +309,87 @@ func signUpWithEmailPassword(c *gin.Context) {
 func signInWithEmailPassword(c *gin.Context) {
 	var req LoginRequest
 
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request body"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
 		return
 	}
 
-	url := os.Getenv("SUPABASE_URL")
-	apiKey := os.Getenv("SUPABASE_KEY")
-
-	dbClient, err := db.NewClient(url, apiKey, nil)
+	dbClient, err := db.NewClient(cfg.Supabase.URL, cfg.Supabase.Key, nil)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Error client initializing"})
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error client initializing"))
 		return
 	}
 
 	session, err := dbClient.SignInWithEmailPassword(req.Email, req.Password)
-	dbClient.UserID = session.User.ID
-
 	if err != nil {
-		c.JSON(401, gin.H{"error": err.Error()})
+		apierror.Respond(c, apierror.Wrap(err, apierror.InvalidCredentials, "Invalid email or password"))
 		return
 	}
+	dbClient.UserID = session.User.ID
 
 	c.JSON(200, gin.H{
 		"message": "Login successful",
 		"session": session,
 	})
 
-	globalClient = dbClient
+	sessions.Put(session, dbClient)
+}
+
+// refreshSession exchanges a refresh token for a new session. The client
+// cached for the previous access token is replaced by one for the new token.
+func refreshSession(c *gin.Context) {
+	logging.Log.Info("Received POST-Request to refresh session")
+
+	var req RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
+		return
+	}
 
-	if err := helpers.ClearOldLetGoEntries(*globalClient); err != nil {
-		logging.Log.Error("Error cleaning up old let_go entries: ", err)
-	} else {
-		logging.Log.Info("Successfully cleaned up old let_go entries.")
+	dbClient, err := db.NewClient(cfg.Supabase.URL, cfg.Supabase.Key, nil)
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error client initializing"))
+		return
 	}
 
+	session, err := dbClient.RefreshToken(req.RefreshToken)
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.InvalidCredentials, "Invalid refresh token"))
+		return
+	}
+	dbClient.UserID = session.User.ID
+
+	if oldToken := bearerToken(c); oldToken != "" {
+		sessions.Remove(oldToken)
+	}
+	sessions.Put(session, dbClient)
+
+	c.JSON(200, gin.H{
+		"message": "Token refresh successful",
+		"session": session,
+	})
 }
 
 func logoutUser(c *gin.Context) {
 	logging.Log.Info("Received POST-Request; requested to Logout User")
 
-	err := globalClient.Auth.Logout()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
-		return
+	// The refresh token is revoked with every backend. Without Supabase
+	// Auth the token was not issued by it and there is nothing to revoke.
+	if cfg.Supabase.AuthEnabled() {
+		dbClient, err := authClient(c)
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error client initializing"))
+			return
+		}
+		if err := dbClient.Auth.Logout(); err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Unavailable, "Logout failed"))
+			return
+		}
 	}
 	logging.Log.Info("User logged out")
 
-	globalClient.UserID = uuid.Nil
+	sessions.Remove(bearerToken(c))
 
 	c.JSON(200, "user logged out")
 
@@ -167,14 +398,9 @@ func logoutUser(c *gin.Context) {
 func getAllUsers(c *gin.Context) {
 	logging.Log.Info("Received GET-Request for user entries")
 
-	if !checkUserAuth() {
-		c.JSON(400, gin.H{"error": "user not logged in"})
-		return
-	}
-
-	profiles, err := models.GetAllUsers(*globalClient)
+	profiles, err := models.GetAllUsers(userStore(c))
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to fetch profiles"))
 		return
 	}
 
@@ -187,168 +413,59 @@ func newEntry(c *gin.Context) {
 	logging.Log.Debug("Received POST-Request to insert new personal entry")
 
 	var raw map[string]interface{}
-	if err := c.BindJSON(&raw); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
 		return
 	}
 
-	table, ok := raw["table"].(string)
-	if !ok || table == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid 'table' key"})
+	entryType, ok := entryTypeFromBody(c, raw)
+	if !ok {
 		return
 	}
+	successorLink(c, entryType, 0)
 
-	if !checkUserAuth() {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not logged in"})
-		return
-	}
-
-	var err error
-	var entry map[string]any
-
-	userID := globalClient.UserID.String()
-	createdAt := time.Now().Format("2006-01-02")
-
-	switch table {
-	case "journal_entries":
-		var persEntry models.PersonalEntry
-
-		jsonBytes, _ := json.Marshal(raw)
-		if err := json.Unmarshal(jsonBytes, &persEntry); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid journal entry structure"})
-			return
-		}
-
-		persEntry.UserId = userID
-		persEntry.CreatedAt = createdAt
-		entry = helpers.ToMap(persEntry)
-
-	case "moon_entries":
-		var moonEntry models.MoonEntry
-
-		jsonBytes, _ := json.Marshal(raw)
-		if err := json.Unmarshal(jsonBytes, &moonEntry); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid moon entry structure"})
-			return
-		}
-		moonEntry.UserId = userID
-		moonEntry.CreatedAt = createdAt
-		entry = helpers.ToMap(moonEntry)
-
-	case "relationship_check":
-		var relEntry models.RelationshipCheckEntry
-
-		jsonBytes, _ := json.Marshal(raw)
-		if err := json.Unmarshal(jsonBytes, &relEntry); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid relationship check structure"})
-			return
-		}
-		relEntry.UserId = userID
-		relEntry.CreatedAt = createdAt
-		entry = helpers.ToMap(relEntry)
-
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown table"})
-		return
-	}
-
-	logging.Log.Infof("Inserting entry into table '%s': %+v", table, entry)
-
-	if err = models.InsertEntry(*globalClient, entry, table); err != nil {
-		logging.Log.Errorf("Error occurred while inserting user entry: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert entry"})
+	if _, ok := insertEntry(c, entryType, raw); !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
-func checkUserAuth() bool {
-	if globalClient == nil || globalClient.UserID.String() == "" {
-		logging.Log.Error("user not logged in")
-		return false
+// entryTypeFromBody looks up the entry type named by the 'table' key of a
+// request body. It answers the request itself if there is none.
+func entryTypeFromBody(c *gin.Context, raw map[string]interface{}) (*models.EntryType, bool) {
+	table, ok := raw["table"].(string)
+	if !ok || table == "" {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Missing or invalid 'table' key"))
+		return nil, false
+	}
+
+	entryType, ok := models.EntryTypeByTable(table)
+	if !ok {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Unknown table"))
+		return nil, false
 	}
 
-	return true
+	return entryType, true
 }
 
 func updateEntry(c *gin.Context) {
-	logging.Log.Debug("Received POST-Request to insert new personal entry")
+	logging.Log.Debug("Received PUT-Request to update an entry")
 
 	var raw map[string]interface{}
-	if err := c.BindJSON(&raw); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
 		return
 	}
 
-	table, ok := raw["table"].(string)
-	if !ok || table == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid 'table' key"})
+	entryType, ok := entryTypeFromBody(c, raw)
+	if !ok {
 		return
 	}
 
-	if !checkUserAuth() {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not logged in"})
-		return
-	}
-
-	var err error
-	var entry map[string]any
-	var entryId int
-
-	userID := globalClient.UserID.String()
-
-	switch table {
-	case "journal_entries":
-		var persEntry models.PersonalEntry
-
-		jsonBytes, _ := json.Marshal(raw)
-		if err := json.Unmarshal(jsonBytes, &persEntry); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid journal entry structure"})
-			return
-		}
-
-		persEntry.UserId = userID
-		entryId = persEntry.EntryID
-
-		entry = helpers.ToMap(persEntry)
-
-	case "moon_entries":
-		var moonEntry models.MoonEntry
-
-		jsonBytes, _ := json.Marshal(raw)
-		if err := json.Unmarshal(jsonBytes, &moonEntry); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid moon entry structure"})
-			return
-		}
-		moonEntry.UserId = userID
-		entryId = moonEntry.EntryID
-
-		entry = helpers.ToMap(moonEntry)
-
-	case "relationship_check":
-		var relEntry models.RelationshipCheckEntry
-
-		jsonBytes, _ := json.Marshal(raw)
-		if err := json.Unmarshal(jsonBytes, &relEntry); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid relationship check structure"})
-			return
-		}
-		relEntry.UserId = userID
-		entryId = relEntry.EntryID
-
-		entry = helpers.ToMap(relEntry)
-
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown table"})
-		return
-	}
-
-	logging.Log.Infof("Inserting entry into table '%s': %+v", table, entry)
-
-	if err = models.UpdateEntry(*globalClient, entry, table, entryId); err != nil {
-		logging.Log.Errorf("Error occurred while inserting user entry: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert entry"})
+	id, _ := raw["id"].(float64)
+	successorLink(c, entryType, int(id))
+	if !modifyEntry(c, entryType, int(id), raw) {
 		return
 	}
 
@@ -360,23 +477,19 @@ func deleteEntry(c *gin.Context) {
 
 	var req DeleteRequest
 
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request body"})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
 		return
 	}
 
-	//Checking if User is logged in
-	if !checkUserAuth() {
-		c.JSON(400, gin.H{"error": "user not logged in"})
+	entryType, ok := models.EntryTypeByTable(req.Table)
+	if !ok {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Unknown table"))
 		return
 	}
+	successorLink(c, entryType, req.Id)
 
-	logging.Log.Debug("Delete from ", req.Table, " where id= ", req.Id)
-
-	err := models.DeleteEntry(*globalClient, req.Table, req.Id)
-	if err != nil {
-		logging.Log.Error("Error occured while deleting entry: ", err.Error())
-		c.JSON(500, gin.H{"error": err.Error()})
+	if !removeEntry(c, entryType, req.Id) {
 		return
 	}
 
@@ -389,23 +502,32 @@ TODO commenting
 func getEntries(c *gin.Context) {
 	logging.Log.Debug("Received GET-Request for user entries")
 
-	if !checkUserAuth() {
-		c.JSON(400, gin.H{"error": "user not logged in"})
-		return
-	}
-
 	sSelectedIndex := c.Query("selected_index")
 	selectedIndex, _ := strconv.Atoi(sSelectedIndex)
+	// Unknown indexes list journal entries.
+	entryType, ok := models.EntryTypeByIndex(selectedIndex)
+	if !ok {
+		entryType, _ = models.EntryTypeByTable("journal_entries")
+	}
+	successorLink(c, entryType, 0)
+
+	filter, ok := listFilter(c)
+	if !ok {
+		return
+	}
+	page, ok := pageRequest(c)
+	if !ok {
+		return
+	}
 
-	entries, err := models.FetchEntries(selectedIndex, *globalClient)
+	entries, err := models.FetchEntries(selectedIndex, userStore(c), currentUserID(c), filter, page)
 	if err != nil {
 		logging.Log.Debug("Error occured while fetching user entries")
-		c.JSON(500, gin.H{"error": err.Error()})
-		logging.Log.Error("error: ", err.Error())
+		respondListError(c, err)
 		return
 	}
 
 	logging.Log.Debug("Returned user entries")
 
-	c.JSON(200, entries)
+	respondPage(c, page, entries)
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"journal-backend/config"
+	"journal-backend/openapi"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, docsUI := range []bool{false, true} {
+		cfg := config.Default()
+		cfg.Server.DocsUI = docsUI
+
+		routes := newRouter(&cfg).Routes()
+		doc := apiSpec(&cfg)
+		if missing := undocumentedRoutes(routes, doc); len(missing) > 0 {
+			t.Errorf("docs UI %v: routes missing from the OpenAPI document: %v", docsUI, missing)
+		}
+
+		for path, item := range doc.Paths {
+			for method := range item {
+				if !hasRoute(routes, method, path) {
+					t.Errorf("docs UI %v: %s %s is documented but not routed", docsUI, method, path)
+				}
+			}
+		}
+	}
+}
+
+func hasRoute(routes gin.RoutesInfo, method, path string) bool {
+	for _, route := range routes {
+		if strings.EqualFold(route.Method, method) && openapi.Path(route.Path) == path {
+			return true
+		}
+	}
+	return false
+}
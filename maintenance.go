@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"journal-backend/apierror"
+	"journal-backend/db"
+	"journal-backend/jobs"
+	"journal-backend/logging"
+	"journal-backend/models"
+	"journal-backend/store"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scheduler runs the maintenance jobs in the background.
+var scheduler *jobs.Scheduler
+
+// startJobs registers the maintenance jobs and starts the scheduler.
+// Schedules can be changed in the jobs section of the configuration.
+func startJobs() {
+	scheduler = jobs.NewScheduler()
+
+	service, err := serviceStore()
+	if err != nil {
+		logging.Log.Warn("Let-go expiry is disabled: ", err)
+	} else {
+		err := scheduler.Add("let-go-expiry", cfg.Jobs.LetGoExpirySchedule, func(ctx context.Context) error {
+			expired, err := models.ExpireLetGo(service, time.Now())
+			for _, e := range expired {
+				logging.Log.Infof("Let-go item of moon entry %d of user %s %s", e.EntryID, e.UserID, e.Action)
+			}
+			return err
+		})
+		if err != nil {
+			logging.Log.Fatal("Error scheduling let-go expiry: ", err)
+		}
+	}
+
+	scheduler.Start()
+}
+
+// serviceStore returns a store that sees the data of all users. With
+// Supabase this needs the service role key, which bypasses row level
+// security.
+func serviceStore() (store.Store, error) {
+	if sharedStore != nil {
+		return sharedStore, nil
+	}
+
+	key := cfg.Supabase.ServiceRoleKey
+	if key == "" {
+		return nil, errors.New("SUPABASE_SERVICE_ROLE_KEY is not set")
+	}
+	client, err := db.NewClient(cfg.Supabase.URL, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewSupabase(client), nil
+}
+
+// requireRole lets only callers whose access token has the given role pass.
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ctxRole) != role {
+			apierror.Respond(c, apierror.New(apierror.Forbidden, "Forbidden"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// listJobs answers GET /admin/jobs with the status of all jobs.
+func listJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": scheduler.Status()})
+}
+
+// runJob answers POST /admin/jobs/:name/run by starting the job now.
+func runJob(c *gin.Context) {
+	name := c.Param("name")
+	logging.Log.Info("Starting job ", name, " on request")
+
+	err := scheduler.Run(name)
+	switch {
+	case errors.Is(err, jobs.ErrUnknownJob):
+		apierror.Respond(c, apierror.New(apierror.NotFound, "Job not found"))
+	case errors.Is(err, jobs.ErrJobRunning):
+		apierror.Respond(c, apierror.New(apierror.Conflict, "Job is already running"))
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Job started"})
+	}
+}
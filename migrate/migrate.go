@@ -0,0 +1,284 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+// lockID is the key of the advisory lock that keeps two runners from
+// migrating the same database at once.
+const lockID = 7460314
+
+// Migration is one versioned schema change. Files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a migration has been applied.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Runner applies and rolls back the embedded migrations.
+type Runner struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewRunner creates a runner for the migrations embedded in the binary.
+func NewRunner(db *sql.DB) (*Runner, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{db: db, migrations: migrations}, nil
+}
+
+// Up applies all pending migrations in order and returns the ones applied.
+func (r *Runner) Up() ([]Migration, error) {
+	var done []Migration
+
+	err := r.locked(func(applied map[int]time.Time) error {
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			err := r.inTx(m.Up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Baseline records the migrations up to and including version as applied
+// without running them, for databases whose schema already exists, such as
+// an existing Supabase project. It returns the migrations recorded.
+func (r *Runner) Baseline(version int) ([]Migration, error) {
+	known := false
+	for _, m := range r.migrations {
+		known = known || m.Version == version
+	}
+	if !known {
+		return nil, fmt.Errorf("no migration with version %d", version)
+	}
+
+	var done []Migration
+
+	err := r.locked(func(applied map[int]time.Time) error {
+		for _, m := range r.migrations {
+			if m.Version > version {
+				break
+			}
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			_, err := r.db.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("baseline %d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the latest steps applied migrations and returns them.
+func (r *Runner) Down(steps int) ([]Migration, error) {
+	var done []Migration
+
+	err := r.locked(func(applied map[int]time.Time) error {
+		for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			err := r.inTx(m.Down, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+			if err != nil {
+				return fmt.Errorf("rollback %d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Status lists all known migrations and when they were applied.
+func (r *Runner) Status() ([]Status, error) {
+	var result []Status
+
+	err := r.locked(func(applied map[int]time.Time) error {
+		for _, m := range r.migrations {
+			status := Status{Migration: m}
+			if at, ok := applied[m.Version]; ok {
+				status.AppliedAt = &at
+			}
+			result = append(result, status)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// locked runs fn while holding the migration lock. fn receives the applied
+// versions from the schema_migrations table, which is created on first use.
+func (r *Runner) locked(fn func(applied map[int]time.Time) error) error {
+	ctx := context.Background()
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+
+	_, err = r.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return fn(applied)
+}
+
+// inTx runs a migration script and its bookkeeping statement atomically.
+func (r *Runner) inTx(script, bookkeeping string, args ...interface{}) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(bookkeeping, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// load reads and pairs the up and down scripts in fsys, ordered by version.
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		base := path.Base(name)
+
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql", base)
+		}
+
+		prefix, label, found := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", base)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", base, err)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d: names %q and %q differ", version, m.Name, label)
+		}
+
+		script := &m.Up
+		if direction == "down" {
+			script = &m.Down
+		}
+		if *script != "" {
+			return nil, fmt.Errorf("migration %s: version %d has two %s scripts", base, version, direction)
+		}
+		*script = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: missing up or down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
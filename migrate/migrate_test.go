@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	script := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+	fsys := fstest.MapFS{
+		"migrations/0010_add_index.up.sql":            script("CREATE INDEX i;"),
+		"migrations/0010_add_index.down.sql":          script("DROP INDEX i;"),
+		"migrations/0002_more_tables.down.sql":        script("DROP TABLE b;"),
+		"migrations/0002_more_tables.up.sql":          script("CREATE TABLE b ();"),
+		"migrations/0001_init.up.sql":                 script("CREATE TABLE a ();"),
+		"migrations/0001_init.down.sql":               script("DROP TABLE a;"),
+		"migrations/9_name_with_underscores.up.sql":   script("SELECT 9;"),
+		"migrations/9_name_with_underscores.down.sql": script("SELECT -9;"),
+		"migrations/README.md":                        script("not a migration"),
+		"other/0003_elsewhere.up.sql":                 script("SELECT 3;"),
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "more_tables", Up: "CREATE TABLE b ();", Down: "DROP TABLE b;"},
+		{Version: 9, Name: "name_with_underscores", Up: "SELECT 9;", Down: "SELECT -9;"},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX i;", Down: "DROP INDEX i;"},
+	}
+	if fmt.Sprint(migrations) != fmt.Sprint(want) {
+		t.Errorf("load() = %v, want %v", migrations, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{name: "no direction", files: []string{"migrations/0001_init.sql"}},
+		{name: "no name", files: []string{"migrations/0001.up.sql", "migrations/0001.down.sql"}},
+		{name: "version not a number", files: []string{"migrations/v1_init.up.sql", "migrations/v1_init.down.sql"}},
+		{name: "missing down", files: []string{"migrations/0001_init.up.sql"}},
+		{name: "missing up", files: []string{"migrations/0001_init.down.sql"}},
+		{name: "names differ", files: []string{"migrations/0001_init.up.sql", "migrations/0001_start.down.sql"}},
+		{name: "same version twice", files: []string{
+			"migrations/0001_init.up.sql", "migrations/0001_init.down.sql",
+			"migrations/1_init.up.sql", "migrations/1_init.down.sql",
+		}},
+	}
+
+	for _, tt := range tests {
+		fsys := fstest.MapFS{}
+		for _, name := range tt.files {
+			fsys[name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+		}
+		if migrations, err := load(fsys); err == nil {
+			t.Errorf("%s: load() = %v, want an error", tt.name, migrations)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s, want version %d: versions must have no gaps", m.Version, m.Name, i+1)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE relationship_check;
+DROP TABLE moon_entries;
+DROP TABLE journal_entries;
+DROP TABLE profiles;
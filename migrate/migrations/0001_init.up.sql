@@ -0,0 +1,40 @@
+-- The schema of the Supabase project this server started on. Databases that
+-- already have it record it with `migrate baseline 1` instead of running it.
+CREATE TABLE profiles (
+    user_id    uuid PRIMARY KEY,
+    username   text NOT NULL,
+    avatar_url integer
+);
+
+CREATE TABLE journal_entries (
+    id               bigserial PRIMARY KEY,
+    user_id          uuid NOT NULL,
+    content          text NOT NULL DEFAULT '',
+    content_grateful text NOT NULL DEFAULT '',
+    content_proud    text NOT NULL DEFAULT '',
+    emotion_color    text NOT NULL DEFAULT '',
+    created_at       date NOT NULL DEFAULT CURRENT_DATE
+);
+
+CREATE INDEX journal_entries_user_created_idx ON journal_entries (user_id, created_at DESC);
+
+CREATE TABLE moon_entries (
+    id         bigserial PRIMARY KEY,
+    user_id    uuid NOT NULL,
+    let_go     jsonb,
+    want       jsonb,
+    moon_sign  text NOT NULL DEFAULT '',
+    created_at date NOT NULL DEFAULT CURRENT_DATE
+);
+
+CREATE INDEX moon_entries_user_created_idx ON moon_entries (user_id, created_at DESC);
+
+CREATE TABLE relationship_check (
+    id         bigserial PRIMARY KEY,
+    user_id    uuid NOT NULL,
+    question   text NOT NULL DEFAULT '',
+    answer     text NOT NULL DEFAULT '',
+    created_at date NOT NULL DEFAULT CURRENT_DATE
+);
+
+CREATE INDEX relationship_check_user_created_idx ON relationship_check (user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX relationship_check_search_idx;
+DROP INDEX moon_entries_search_idx;
+DROP INDEX journal_entries_search_idx;
@@ -0,0 +1,12 @@
+-- The expressions must match the ones built by store.Postgres.Search.
+CREATE INDEX journal_entries_search_idx ON journal_entries USING gin (
+    to_tsvector('simple', coalesce(content::text, '') || ' ' || coalesce(content_grateful::text, '') || ' ' || coalesce(content_proud::text, ''))
+);
+
+CREATE INDEX moon_entries_search_idx ON moon_entries USING gin (
+    to_tsvector('simple', coalesce(let_go::text, '') || ' ' || coalesce(want::text, ''))
+);
+
+CREATE INDEX relationship_check_search_idx ON relationship_check USING gin (
+    to_tsvector('simple', coalesce(question::text, '') || ' ' || coalesce(answer::text, ''))
+);
@@ -0,0 +1,15 @@
+-- Every row keeps the day it was written on in the zone of its writer.
+ALTER TABLE journal_entries
+    ALTER COLUMN created_at TYPE date USING (created_at AT TIME ZONE timezone)::date,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_DATE,
+    DROP COLUMN timezone;
+
+ALTER TABLE moon_entries
+    ALTER COLUMN created_at TYPE date USING (created_at AT TIME ZONE timezone)::date,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_DATE,
+    DROP COLUMN timezone;
+
+ALTER TABLE relationship_check
+    ALTER COLUMN created_at TYPE date USING (created_at AT TIME ZONE timezone)::date,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_DATE,
+    DROP COLUMN timezone;
@@ -0,0 +1,15 @@
+-- Date-only rows were written in the server's zone, which is taken as UTC.
+ALTER TABLE journal_entries
+    ALTER COLUMN created_at TYPE timestamptz USING created_at::timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ADD COLUMN timezone text NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE moon_entries
+    ALTER COLUMN created_at TYPE timestamptz USING created_at::timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ADD COLUMN timezone text NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE relationship_check
+    ALTER COLUMN created_at TYPE timestamptz USING created_at::timestamp AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ADD COLUMN timezone text NOT NULL DEFAULT 'UTC';
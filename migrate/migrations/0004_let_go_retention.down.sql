@@ -0,0 +1,6 @@
+DROP TABLE let_go_audit;
+DROP TABLE let_go_archive;
+
+ALTER TABLE profiles
+    DROP COLUMN let_go_archive,
+    DROP COLUMN let_go_expire_hours;
@@ -0,0 +1,27 @@
+-- A NULL let_go_expire_hours means the default policy: delete after 24 hours.
+ALTER TABLE profiles
+    ADD COLUMN let_go_expire_hours integer CHECK (let_go_expire_hours > 0),
+    ADD COLUMN let_go_archive boolean NOT NULL DEFAULT false;
+
+CREATE TABLE let_go_archive (
+    id               bigserial PRIMARY KEY,
+    user_id          uuid NOT NULL,
+    entry_id         bigint NOT NULL,
+    let_go           jsonb NOT NULL,
+    entry_created_at timestamptz NOT NULL,
+    archived_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX let_go_archive_user_idx ON let_go_archive (user_id, archived_at DESC);
+
+-- The audit log records which items were removed, not their content.
+CREATE TABLE let_go_audit (
+    id               bigserial PRIMARY KEY,
+    user_id          uuid NOT NULL,
+    entry_id         bigint NOT NULL,
+    action           text NOT NULL CHECK (action IN ('deleted', 'archived')),
+    entry_created_at timestamptz NOT NULL,
+    removed_at       timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX let_go_audit_user_idx ON let_go_audit (user_id, removed_at DESC);
@@ -0,0 +1,5 @@
+DROP POLICY IF EXISTS let_go_audit_select_own ON let_go_audit;
+DROP POLICY IF EXISTS let_go_archive_select_own ON let_go_archive;
+
+ALTER TABLE let_go_audit DISABLE ROW LEVEL SECURITY;
+ALTER TABLE let_go_archive DISABLE ROW LEVEL SECURITY;
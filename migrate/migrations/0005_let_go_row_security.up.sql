@@ -0,0 +1,24 @@
+-- Supabase exposes the public schema through PostgREST. Users may read
+-- their own archive and audit rows; only the server writes them, with the
+-- service role key, which bypasses row level security. On plain PostgreSQL
+-- there is no auth schema and the server connects as the table owner, so
+-- enabling row level security changes nothing there.
+ALTER TABLE let_go_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE let_go_audit ENABLE ROW LEVEL SECURITY;
+
+DO $$
+BEGIN
+    IF to_regprocedure('auth.uid()') IS NOT NULL THEN
+        CREATE POLICY let_go_archive_select_own ON let_go_archive
+            FOR SELECT USING (user_id = auth.uid());
+        CREATE POLICY let_go_audit_select_own ON let_go_audit
+            FOR SELECT USING (user_id = auth.uid());
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'anon') THEN
+        REVOKE INSERT, UPDATE, DELETE ON let_go_archive, let_go_audit FROM anon;
+    END IF;
+    IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'authenticated') THEN
+        REVOKE INSERT, UPDATE, DELETE ON let_go_archive, let_go_audit FROM authenticated;
+    END IF;
+END $$;
@@ -0,0 +1 @@
+DROP FUNCTION search_entries(uuid, text, text[], integer);
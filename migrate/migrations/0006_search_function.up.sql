@@ -0,0 +1,39 @@
+-- search_entries is the full-text search of store.Postgres.Search for
+-- clients that reach the database only through PostgREST, like the Supabase
+-- backend. The expressions must match the indexes of 0002_search_indexes.
+-- It runs with the rights of the caller, so row level security still
+-- applies. Matches are marked with U+E000 and U+E001 for the server to
+-- escape and highlight.
+CREATE FUNCTION search_entries(search_user uuid, terms text, tables text[], max_results integer)
+RETURNS TABLE (entry_table text, id bigint, created_at timestamptz, rank real, snippet text)
+LANGUAGE sql STABLE SECURITY INVOKER
+AS $$
+    WITH query AS (
+        SELECT to_tsquery('simple', terms) AS q,
+               'StartSel=' || chr(57344) || ', StopSel=' || chr(57345) || ', MaxWords=20, MinWords=5' AS options
+    ), documents AS (
+        SELECT 'journal_entries' AS entry_table, e.id, e.created_at,
+               coalesce(content::text, '') || ' ' || coalesce(content_grateful::text, '') || ' ' || coalesce(content_proud::text, '') AS document
+        FROM journal_entries e, query
+        WHERE 'journal_entries' = ANY (tables) AND e.user_id = search_user
+          AND to_tsvector('simple', coalesce(content::text, '') || ' ' || coalesce(content_grateful::text, '') || ' ' || coalesce(content_proud::text, '')) @@ query.q
+        UNION ALL
+        SELECT 'moon_entries', e.id, e.created_at,
+               coalesce(let_go::text, '') || ' ' || coalesce(want::text, '')
+        FROM moon_entries e, query
+        WHERE 'moon_entries' = ANY (tables) AND e.user_id = search_user
+          AND to_tsvector('simple', coalesce(let_go::text, '') || ' ' || coalesce(want::text, '')) @@ query.q
+        UNION ALL
+        SELECT 'relationship_check', e.id, e.created_at,
+               coalesce(question::text, '') || ' ' || coalesce(answer::text, '')
+        FROM relationship_check e, query
+        WHERE 'relationship_check' = ANY (tables) AND e.user_id = search_user
+          AND to_tsvector('simple', coalesce(question::text, '') || ' ' || coalesce(answer::text, '')) @@ query.q
+    )
+    SELECT d.entry_table, d.id, d.created_at,
+           ts_rank(to_tsvector('simple', d.document), query.q),
+           ts_headline('simple', d.document, query.q, query.options)
+    FROM documents d, query
+    ORDER BY 4 DESC, d.id DESC
+    LIMIT max_results;
+$$;
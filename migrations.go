@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"journal-backend/logging"
+	"journal-backend/migrate"
+	"strconv"
+
+	_ "github.com/lib/pq"
+)
+
+const migrateUsage = "usage: journal-backend migrate up | down [steps] | baseline <version> | status"
+
+// runMigrate implements the migrate subcommand against DATABASE_URL.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		logging.Log.Fatal(migrateUsage)
+	}
+
+	if cfg.Storage.DatabaseURL == "" {
+		logging.Log.Fatal("DATABASE_URL (storage.database_url) is required")
+	}
+
+	conn, err := sql.Open("postgres", cfg.Storage.DatabaseURL)
+	if err != nil {
+		logging.Log.Fatal("Error connecting to postgres: ", err)
+	}
+	defer conn.Close()
+
+	runner, err := migrate.NewRunner(conn)
+	if err != nil {
+		logging.Log.Fatal("Error loading migrations: ", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up()
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logging.Log.Fatal("Error applying migrations: ", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				logging.Log.Fatal(migrateUsage)
+			}
+		}
+
+		rolledBack, err := runner.Down(steps)
+		for _, m := range rolledBack {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logging.Log.Fatal("Error rolling back migrations: ", err)
+		}
+
+	case "baseline":
+		if len(args) != 2 {
+			logging.Log.Fatal(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			logging.Log.Fatal(migrateUsage)
+		}
+
+		recorded, err := runner.Baseline(version)
+		for _, m := range recorded {
+			fmt.Printf("recorded %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logging.Log.Fatal("Error recording baseline: ", err)
+		}
+
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			logging.Log.Fatal("Error reading migration status: ", err)
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, appliedAt)
+		}
+
+	default:
+		logging.Log.Fatal(migrateUsage)
+	}
+}
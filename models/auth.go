@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"journal-backend/apierror"
 	"journal-backend/logging"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,99 +16,97 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-func LoginHandler(c *gin.Context) {
-	url := os.Getenv("SUPABASE_URL")
-	apiKey := os.Getenv("SUPABASE_KEY")
-
-	var req LoginRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ungültige Daten"})
-		return
-	}
-
-	payload := map[string]string{
-		"email":    req.Email,
-		"password": req.Password,
-	}
-
-	body, _ := json.Marshal(payload)
-
-	supabaseReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
-	if err != nil {
-		logging.Log.Info("Fehler beim Erstellen der Anfrage:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "interner Fehler"})
-		return
-	}
-
-	supabaseReq.Header.Set("apikey", apiKey)
-	supabaseReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(supabaseReq)
-	if err != nil {
-		logging.Log.Info("error while sending:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "interner Fehler"})
-		return
+// LoginHandler signs users in by forwarding their credentials to the auth
+// endpoint url with apiKey.
+func LoginHandler(url, apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
+			return
+		}
+
+		payload := map[string]string{
+			"email":    req.Email,
+			"password": req.Password,
+		}
+
+		body, _ := json.Marshal(payload)
+
+		supabaseReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error creating the auth request"))
+			return
+		}
+
+		supabaseReq.Header.Set("apikey", apiKey)
+		supabaseReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(supabaseReq)
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Unavailable, "Auth service not reachable"))
+			return
+		}
+		defer resp.Body.Close()
+
+		respBody, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode != 200 {
+			logging.Log.Info("Supabase login failed: ", string(respBody))
+			apierror.Respond(c, apierror.New(apierror.InvalidCredentials, "Invalid email or password"))
+			return
+		}
+
+		var authData map[string]interface{}
+		json.Unmarshal(respBody, &authData)
+
+		c.JSON(http.StatusOK, authData)
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 200 {
-		logging.Log.Info("Supabase Login fehlgeschlagen:", string(respBody))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login fehlgeschlagen"})
-		return
-	}
-
-	var authData map[string]interface{}
-	json.Unmarshal(respBody, &authData)
-
-	c.JSON(http.StatusOK, authData)
 }
 
-func RegisterHandler(c *gin.Context) {
-	url := os.Getenv("SUPABASE_URL")
-	apiKey := os.Getenv("SUPABASE_KEY")
-
-	var req LoginRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ungültige Daten"})
-		return
-	}
-
-	payload := map[string]string{
-		"email":    req.Email,
-		"password": req.Password,
+// RegisterHandler signs users up by forwarding their credentials to the
+// auth endpoint url with apiKey.
+func RegisterHandler(url, apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
+			return
+		}
+
+		payload := map[string]string{
+			"email":    req.Email,
+			"password": req.Password,
+		}
+		body, _ := json.Marshal(payload)
+
+		supabaseReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Error creating the auth request"))
+			return
+		}
+
+		supabaseReq.Header.Set("apikey", apiKey)
+		supabaseReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(supabaseReq)
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Unavailable, "Auth service not reachable"))
+			return
+		}
+		defer resp.Body.Close()
+
+		respBody, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode >= 400 {
+			logging.Log.Info("Supabase registration failed: ", string(respBody))
+			apierror.Respond(c, apierror.New(apierror.RegistrationFailed, "Registration failed"))
+			return
+		}
+
+		var authData map[string]interface{}
+		json.Unmarshal(respBody, &authData)
+
+		c.JSON(http.StatusOK, authData)
 	}
-	body, _ := json.Marshal(payload)
-
-	supabaseReq, err := http.NewRequest("POST", url, bytes.NewReader(body))
-	if err != nil {
-		logging.Log.Info("Fehler beim Erstellen der Anfrage:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "interner Fehler"})
-		return
-	}
-
-	supabaseReq.Header.Set("apikey", apiKey)
-	supabaseReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(supabaseReq)
-	if err != nil {
-		logging.Log.Info("Fehler beim Senden:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "interner Fehler"})
-		return
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode >= 400 {
-		logging.Log.Info("registration failed:", string(respBody))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "registration failed"})
-		return
-	}
-
-	var authData map[string]interface{}
-	json.Unmarshal(respBody, &authData)
-
-	c.JSON(http.StatusOK, authData)
 }
@@ -2,15 +2,18 @@ package models
 
 import (
 	"encoding/json"
-	"journal-backend/db"
+	"errors"
 	"journal-backend/helpers"
 	"journal-backend/logging"
-	"strconv"
-
-	"github.com/supabase-community/postgrest-go"
+	"journal-backend/store"
 )
 
-// wird gerade nicht genutzt, da entries als []map[string]interface{} zurückgegeben werden, ohne struct
+// ErrUnknownTable is returned for tables that are not entry tables.
+var ErrUnknownTable = errors.New("unknown table")
+
+// PersonalEntry is a journal entry. Entries of all types are answered with
+// the JSON field names of their struct; created_at is an RFC 3339 timestamp
+// in UTC and timezone the IANA timezone of the writer.
 type PersonalEntry struct {
 	EntryID         int    `json:"id,omitempty"`
 	UserId          string `json:"user_id"`
@@ -19,8 +22,11 @@ type PersonalEntry struct {
 	ContentProud    string `json:"content_proud"`
 	EmotionColor    string `json:"emotion_color"`
 	CreatedAt       string `json:"created_at"`
+	Timezone        string `json:"timezone"`
 }
 
+// MoonEntry is written at new or full moon. let_go and want are lists of
+// strings; moon_sign is the sign the moon was in.
 type MoonEntry struct {
 	EntryID   int             `json:"id,omitempty"`
 	UserId    string          `json:"user_id"`
@@ -28,78 +34,95 @@ type MoonEntry struct {
 	Want      json.RawMessage `json:"want"`
 	MoonSign  string          `json:"moon_sign"`
 	CreatedAt string          `json:"created_at"`
+	Timezone  string          `json:"timezone"`
 }
 
+// RelationshipCheckEntry answers a question about a relationship.
 type RelationshipCheckEntry struct {
 	EntryID   int    `json:"id,omitempty"`
 	UserId    string `json:"user_id"`
 	Question  string `json:"question"`
 	Answer    string `json:"answer"`
 	CreatedAt string `json:"created_at"`
+	Timezone  string `json:"timezone"`
 }
 
-func FetchEntries(selectedIndex int, dbClient db.Client) ([]map[string]interface{}, error) {
-	var table, selectFields string
-	var result []map[string]interface{}
-
-	switch selectedIndex {
-	case 0:
-		table = "journal_entries"
-		selectFields = "id, content,content_grateful,content_proud,emotion_color,created_at"
-	case 1:
-		table = "moon_entries"
-		selectFields = "id, let_go,want,created_at,moon_sign"
-	case 2:
-		table = "relationship_check"
-		selectFields = "id, question,answer,created_at"
-	default:
-		table = "journal_entries"
-		selectFields = "*"
+func FetchEntries(selectedIndex int, entries store.EntryStore, userID string, filter Filter, page PageRequest) (Page, error) {
+	entryType, ok := EntryTypeByIndex(selectedIndex)
+	if !ok {
+		entryType, _ = EntryTypeByTable("journal_entries")
+		return selectEntries(entries, entryType, "*", userID, filter, page)
 	}
 
-	_, err := dbClient.
-		From(table).
-		Select(selectFields, "", false).
-		Eq("user_id", dbClient.UserID.String()).
-		Order("created_at", &postgrest.OrderOpts{Ascending: false}).
-		ExecuteTo(&result)
+	return ListEntries(entryType, entries, userID, filter, page)
+}
 
+// ListEntries returns the entries of entryType written by userID that match
+// filter, newest first unless the filter asks otherwise.
+func ListEntries(entryType *EntryType, entries store.EntryStore, userID string, filter Filter, page PageRequest) (Page, error) {
+	return selectEntries(entries, entryType, entryType.Columns, userID, filter, page)
+}
+
+// GetEntry returns the entry of entryType with entryId if it was written by
+// userID, with the same columns ListEntries returns.
+func GetEntry(entryType *EntryType, entries store.EntryStore, entryId int, userID string) (Entry, error) {
+	logging.Log.Debug("Select from ", entryType.Table, " where id= ", entryId)
+
+	row, err := entries.Get(entryType.Table, entryType.Columns, entryId, userID)
 	if err != nil {
-		logging.Log.Error("error: ", err.Error())
 		return nil, err
 	}
-	return result, nil
+
+	return entryType.FromRow(row)
 }
 
-func InsertEntry(dbClient db.Client, entry interface{}, table string) error {
+func selectEntries(entries store.EntryStore, entryType *EntryType, selectFields, userID string, filter Filter, page PageRequest) (Page, error) {
+	q := store.Query{Table: entryType.Table, Columns: selectFields, UserID: userID}
+	if err := filter.apply(entryType, &q); err != nil {
+		return Page{}, err
+	}
 
-	_, _, err := dbClient.
-		From(table).
-		Insert(entry, false, "", "*", "").
-		Eq("user_id", dbClient.UserID.String()).
-		Execute()
+	result, err := fetchPage(entries, entryType, q, page)
+	if err != nil {
+		logging.Log.Error("error: ", err.Error())
+		return Page{}, err
+	}
+	return result, nil
+}
+
+func InsertEntry(entries store.EntryStore, entry map[string]interface{}, table string) (Entry, error) {
+	entryType, ok := EntryTypeByTable(table)
+	if !ok {
+		return nil, ErrUnknownTable
+	}
 
+	inserted, err := entries.Insert(table, entry)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return entryType.FromRow(inserted)
 }
 
-func UpdateEntry(dbClient db.Client, entry map[string]interface{}, table string, entryId int) error {
+// immutableFields are set once when an entry is created and never taken
+// from an update.
+var immutableFields = []string{"id", "created_at"}
+
+func UpdateEntry(entries store.EntryStore, entry map[string]interface{}, table string, entryId int, userID string) error {
 
-	sID := strconv.FormatInt(int64(entryId), 10)
 	logging.Log.Debug("Update entry in ", table, " where id= ", entryId)
 
 	filtered := helpers.FilterEmptyFields(entry)
+	for _, field := range immutableFields {
+		delete(filtered, field)
+	}
+	if entryType, ok := EntryTypeByTable(table); ok {
+		for _, field := range entryType.Derived {
+			delete(filtered, field)
+		}
+	}
 
-	_, _, err := dbClient.
-		From(table).
-		Update(filtered, "", "").
-		Eq("id", sID).
-		Eq("user_id", dbClient.UserID.String()).
-		Execute()
-
+	err := entries.Update(table, entryId, userID, filtered)
 	if err != nil {
 		return err
 	}
@@ -107,17 +130,15 @@ func UpdateEntry(dbClient db.Client, entry map[string]interface{}, table string,
 	return nil
 }
 
-func DeleteEntry(dbClient db.Client, table string, entryId int8) error {
+func DeleteEntry(entries store.EntryStore, table string, entryId int, userID string) error {
 
-	sID := strconv.FormatInt(int64(entryId), 10)
-	logging.Log.Debug("Delete from ", table, " where id= ", entryId)
+	if _, ok := EntryTypeByTable(table); !ok {
+		return ErrUnknownTable
+	}
 
-	_, _, err := dbClient.
-		From(table).
-		Delete("", "exact").
-		Eq("id", sID).
-		Execute()
+	logging.Log.Debug("Delete from ", table, " where id= ", entryId)
 
+	err := entries.Delete(table, entryId, userID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"journal-backend/store"
+	"slices"
+	"time"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Filter narrows down and orders an entry listing.
+type Filter struct {
+	// From and To bound created_at, both inclusive. They are dates
+	// (2006-01-02), which cover the whole day in Location, or RFC 3339
+	// timestamps.
+	From string
+	To   string
+	// Location is the timezone of dates in From and To. Nil means UTC.
+	Location *time.Location
+	// Equal maps columns of the entry type to the value they must have.
+	// Values of columns with a OneOf rule are matched ignoring case.
+	Equal map[string]string
+	// Ascending lists the oldest entries first.
+	Ascending bool
+}
+
+// apply validates f against entryType and adds it to q.
+func (f Filter) apply(entryType *EntryType, q *store.Query) error {
+	for column, value := range f.Equal {
+		if !slices.Contains(entryType.Filters, column) {
+			return fmt.Errorf("%w: %s cannot be filtered by %q", ErrInvalidFilter, entryType.Name, column)
+		}
+		if rule, ok := entryType.rule(column); ok && len(rule.OneOf) > 0 {
+			canonical, ok := rule.canonical(value)
+			if !ok {
+				return fmt.Errorf("%w: %s %s", ErrInvalidFilter, column, rule.oneOfMessage())
+			}
+			value = canonical
+		}
+		if q.Equal == nil {
+			q.Equal = make(map[string]string)
+		}
+		q.Equal[column] = value
+	}
+
+	return f.applyRange(q)
+}
+
+// applyRange adds the created_at range and the order of f to q.
+func (f Filter) applyRange(q *store.Query) error {
+	if f.From != "" {
+		from, _, err := parseBound(f.From, f.Location)
+		if err != nil {
+			return fmt.Errorf("%w: from: %v", ErrInvalidFilter, err)
+		}
+		q.From = from
+	}
+
+	if f.To != "" {
+		to, dateOnly, err := parseBound(f.To, f.Location)
+		if err != nil {
+			return fmt.Errorf("%w: to: %v", ErrInvalidFilter, err)
+		}
+		if dateOnly {
+			q.Until = to.AddDate(0, 0, 1)
+		} else {
+			q.Until = to.Add(time.Microsecond)
+		}
+	}
+
+	if !q.From.IsZero() && !q.Until.IsZero() && !q.From.Before(q.Until) {
+		return fmt.Errorf("%w: from is after to", ErrInvalidFilter)
+	}
+
+	q.Ascending = f.Ascending
+	return nil
+}
+
+// parseBound parses a date, which starts at midnight in loc, or an RFC 3339
+// timestamp.
+func parseBound(s string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true, nil
+	}
+
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 timestamp, got %q", s)
+	}
+	return t, false, nil
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"journal-backend/store"
+	"sort"
+	"time"
+)
+
+// Periods mood statistics can be grouped by.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// DefaultStatsDays is the length of the range used when no from is given.
+const DefaultStatsDays = 30
+
+// MaxStatsDays limits the days mood statistics may cover per period, so a
+// single request cannot build millions of periods.
+var MaxStatsDays = map[string]int{
+	PeriodDay:   366,
+	PeriodWeek:  3 * 366,
+	PeriodMonth: 5 * 366,
+}
+
+var ErrInvalidStatsQuery = errors.New("invalid statistics query")
+
+// MoodQuery selects the journal entries of a user for mood statistics.
+// From and To are days in Location and both included.
+type MoodQuery struct {
+	UserID   string
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Period   string
+}
+
+// MoodPeriod is the mood distribution of one day, week or month.
+type MoodPeriod struct {
+	Start    string         `json:"start"`
+	Entries  int            `json:"entries"`
+	Colors   map[string]int `json:"colors"`
+	Dominant string         `json:"dominant"`
+}
+
+// MoodStreak is a run of consecutive days with the same dominant mood.
+type MoodStreak struct {
+	Color  string `json:"color"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Length int    `json:"length"`
+}
+
+// MoodStats summarizes the emotion colors of a user's journal entries.
+type MoodStats struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Timezone     string         `json:"timezone"`
+	Period       string         `json:"period"`
+	Entries      int            `json:"entries"`
+	Distribution map[string]int `json:"distribution"`
+	Dominant     string         `json:"dominant"`
+	Periods      []MoodPeriod   `json:"periods"`
+	Streaks      []MoodStreak   `json:"streaks"`
+	Longest      *MoodStreak    `json:"longest_streak"`
+}
+
+// MoodStatistics groups the emotion colors of the journal entries in q by
+// period. Entries without a color are counted but have no mood. Streaks
+// are runs of at least two days with the same dominant mood.
+func MoodStatistics(entries store.EntryStore, q MoodQuery) (MoodStats, error) {
+	switch q.Period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return MoodStats{}, fmt.Errorf("%w: period must be day, week or month", ErrInvalidStatsQuery)
+	}
+	if q.To.Before(q.From) {
+		return MoodStats{}, fmt.Errorf("%w: from is after to", ErrInvalidStatsQuery)
+	}
+	if limit := MaxStatsDays[q.Period]; rangeDays(q.From, q.To) > limit {
+		return MoodStats{}, fmt.Errorf("%w: %s statistics cover at most %d days", ErrInvalidStatsQuery, q.Period, limit)
+	}
+
+	days, err := dailyColors(entries, q)
+	if err != nil {
+		return MoodStats{}, err
+	}
+
+	stats := MoodStats{
+		From:         q.From.Format("2006-01-02"),
+		To:           q.To.Format("2006-01-02"),
+		Timezone:     q.Location.String(),
+		Period:       q.Period,
+		Distribution: make(map[string]int),
+		Periods:      []MoodPeriod{},
+		Streaks:      []MoodStreak{},
+	}
+
+	byStart := make(map[string]int)
+	for day := q.From; !day.After(q.To); day = day.AddDate(0, 0, 1) {
+		start := periodStart(day, q.Period).Format("2006-01-02")
+		i, ok := byStart[start]
+		if !ok {
+			i = len(stats.Periods)
+			byStart[start] = i
+			stats.Periods = append(stats.Periods, MoodPeriod{Start: start, Colors: make(map[string]int)})
+		}
+
+		for _, color := range days[day.Format("2006-01-02")] {
+			stats.Periods[i].Entries++
+			stats.Entries++
+			if color != "" {
+				stats.Periods[i].Colors[color]++
+				stats.Distribution[color]++
+			}
+		}
+	}
+
+	for i := range stats.Periods {
+		stats.Periods[i].Dominant = dominant(stats.Periods[i].Colors)
+	}
+	stats.Dominant = dominant(stats.Distribution)
+
+	stats.Streaks = moodStreaks(days, q.From, q.To)
+	for i := range stats.Streaks {
+		if stats.Longest == nil || stats.Streaks[i].Length > stats.Longest.Length {
+			stats.Longest = &stats.Streaks[i]
+		}
+	}
+
+	return stats, nil
+}
+
+// dailyColors returns the emotion colors of the entries in q by local day.
+func dailyColors(entries store.EntryStore, q MoodQuery) (map[string][]string, error) {
+	// The stored times are widened by a day on both sides, so entries near
+	// midnight are found whatever the offset of the location. They are
+	// narrowed down to the local days afterwards.
+	from := time.Date(q.From.Year(), q.From.Month(), q.From.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(q.To.Year(), q.To.Month(), q.To.Day(), 0, 0, 0, 0, time.UTC)
+	rows, err := entries.Select(store.Query{
+		Table:   "journal_entries",
+		Columns: "id, emotion_color, created_at",
+		UserID:  q.UserID,
+		From:    from.AddDate(0, 0, -1),
+		Until:   to.AddDate(0, 0, 2),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string][]string)
+	for _, row := range rows {
+		createdAt, _ := row["created_at"].(string)
+		day := LocalDay(createdAt, q.Location)
+		if day.IsZero() || day.Before(q.From) || day.After(q.To) {
+			continue
+		}
+
+		color, _ := row["emotion_color"].(string)
+		key := day.Format("2006-01-02")
+		days[key] = append(days[key], color)
+	}
+
+	return days, nil
+}
+
+// moodStreaks finds the runs of consecutive days between from and to that
+// share a dominant mood, oldest first.
+func moodStreaks(days map[string][]string, from, to time.Time) []MoodStreak {
+	streaks := []MoodStreak{}
+	var current *MoodStreak
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		counts := make(map[string]int)
+		for _, color := range days[key] {
+			if color != "" {
+				counts[color]++
+			}
+		}
+		color := dominant(counts)
+
+		if current != nil && color != "" && color == current.Color {
+			current.End = key
+			current.Length++
+			continue
+		}
+		if current != nil && current.Length >= 2 {
+			streaks = append(streaks, *current)
+		}
+		current = nil
+		if color != "" {
+			current = &MoodStreak{Color: color, Start: key, End: key, Length: 1}
+		}
+	}
+	if current != nil && current.Length >= 2 {
+		streaks = append(streaks, *current)
+	}
+
+	return streaks
+}
+
+// dominant returns the most frequent color. Ties go to the color that sorts
+// first, so the result does not change between requests.
+func dominant(counts map[string]int) string {
+	colors := make([]string, 0, len(counts))
+	for color := range counts {
+		colors = append(colors, color)
+	}
+	sort.Strings(colors)
+
+	best := ""
+	for _, color := range colors {
+		if best == "" || counts[color] > counts[best] {
+			best = color
+		}
+	}
+	return best
+}
+
+// periodStart returns the first day of the day, ISO week or month of day.
+func periodStart(day time.Time, period string) time.Time {
+	switch period {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day
+	}
+}
+
+// LocalDay returns the day a created_at value falls on in loc, at midnight
+// in loc. Plain dates are taken as they are. It returns the zero time if
+// createdAt cannot be parsed.
+func LocalDay(createdAt string, loc *time.Location) time.Time {
+	if day, err := time.ParseInLocation("2006-01-02", createdAt, loc); err == nil {
+		return day
+	}
+
+	t := store.ParseTime(createdAt)
+	if t.IsZero() {
+		return t
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// rangeDays returns the number of calendar days from from to to, both
+// included. Ranges too long for a time.Duration count as very long.
+func rangeDays(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours()/24) + 1
+}
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"fmt"
+	"journal-backend/store"
+	"sort"
+	"testing"
+	"time"
+)
+
+// insertMoods adds journal entries of testUser, one per created_at, with
+// the emotion color after the space, e.g. "2024-05-01T08:00:00Z Red".
+func insertMoods(t *testing.T, m *store.Memory, entries ...string) {
+	t.Helper()
+	for _, entry := range entries {
+		var createdAt, color string
+		fmt.Sscan(entry, &createdAt, &color)
+		_, err := m.Insert("journal_entries", map[string]interface{}{
+			"user_id":       testUser,
+			"created_at":    createdAt,
+			"emotion_color": color,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// periodSummary writes the periods as start:entries:dominant.
+func periodSummary(periods []MoodPeriod) []string {
+	summary := make([]string, len(periods))
+	for i, p := range periods {
+		summary[i] = fmt.Sprintf("%s:%d:%s", p.Start, p.Entries, p.Dominant)
+	}
+	return summary
+}
+
+func streakSummary(streaks []MoodStreak) []string {
+	summary := make([]string, len(streaks))
+	for i, s := range streaks {
+		summary[i] = fmt.Sprintf("%s:%s..%s:%d", s.Color, s.Start, s.End, s.Length)
+	}
+	return summary
+}
+
+func TestMoodStatisticsPeriods(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	m := store.NewMemory()
+	insertMoods(t, m,
+		"2024-01-30T08:00:00Z Red",
+		"2024-01-31T08:00:00Z Blue",
+		"2024-02-01T08:00:00Z Blue",
+		"2024-02-29T23:30:00Z Green", // 2024-03-01 in Berlin
+		"2024-12-28T08:00:00Z Red",   // Saturday
+		"2024-12-29T08:00:00Z",       // Sunday, no color
+		"2024-12-29T23:30:00Z Blue",  // Monday 2024-12-30 in Berlin
+		"2025-01-05T08:00:00Z Blue",  // Sunday
+		"2025-01-06T08:00:00Z Red",   // Monday
+	)
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02", s, berlin)
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		period   string
+		want     []string
+	}{
+		{
+			// ISO weeks start on Monday, also across the turn of the year.
+			name: "weeks", from: "2024-12-28", to: "2025-01-06", period: PeriodWeek,
+			want: []string{"2024-12-23:2:Red", "2024-12-30:2:Blue", "2025-01-06:1:Red"},
+		},
+		{
+			name: "weeks starting mid-week", from: "2025-01-01", to: "2025-01-06", period: PeriodWeek,
+			want: []string{"2024-12-30:1:Blue", "2025-01-06:1:Red"},
+		},
+		{
+			name: "months", from: "2024-01-30", to: "2024-03-01", period: PeriodMonth,
+			want: []string{"2024-01-01:2:Blue", "2024-02-01:1:Blue", "2024-03-01:1:Green"},
+		},
+		{
+			name: "days", from: "2024-12-28", to: "2024-12-31", period: PeriodDay,
+			want: []string{"2024-12-28:1:Red", "2024-12-29:1:", "2024-12-30:1:Blue", "2024-12-31:0:"},
+		},
+	}
+
+	for _, tt := range tests {
+		stats, err := MoodStatistics(m, MoodQuery{UserID: testUser, From: day(tt.from), To: day(tt.to), Location: berlin, Period: tt.period})
+		if err != nil {
+			t.Fatalf("%s: MoodStatistics() error = %v", tt.name, err)
+		}
+		if got := periodSummary(stats.Periods); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: periods = %v, want %v", tt.name, got, tt.want)
+		}
+
+		total := 0
+		for _, p := range stats.Periods {
+			total += p.Entries
+		}
+		if stats.Entries != total {
+			t.Errorf("%s: Entries = %d, want the sum of the periods %d", tt.name, stats.Entries, total)
+		}
+	}
+}
+
+func TestMoodStatisticsDominant(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+		want   string
+	}{
+		{name: "none", counts: map[string]int{}, want: ""},
+		{name: "single", counts: map[string]int{"Red": 1}, want: "Red"},
+		{name: "most frequent", counts: map[string]int{"Blue": 1, "Red": 2}, want: "Red"},
+		{name: "tie goes to the first name", counts: map[string]int{"Red": 2, "Blue": 2, "Yellow": 1}, want: "Blue"},
+		{name: "three-way tie", counts: map[string]int{"Yellow": 3, "Green": 3, "Red": 3}, want: "Green"},
+	}
+
+	for _, tt := range tests {
+		// The order of a map is random; ask often enough to notice.
+		for i := 0; i < 20; i++ {
+			if got := dominant(tt.counts); got != tt.want {
+				t.Errorf("%s: dominant(%v) = %q, want %q", tt.name, tt.counts, got, tt.want)
+				break
+			}
+		}
+	}
+
+	m := store.NewMemory()
+	insertMoods(t, m,
+		"2024-05-01T08:00:00Z Red",
+		"2024-05-01T09:00:00Z Blue",
+		"2024-05-02T08:00:00Z Red",
+		"2024-05-02T09:00:00Z Blue",
+		"2024-05-02T10:00:00Z",
+	)
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	stats, err := MoodStatistics(m, MoodQuery{UserID: testUser, From: from, To: from.AddDate(0, 0, 1), Location: time.UTC, Period: PeriodDay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Dominant != "Blue" || stats.Entries != 5 || fmt.Sprint(stats.Distribution) != "map[Blue:2 Red:2]" {
+		t.Errorf("MoodStatistics() = dominant %q, %d entries, %v, want Blue, 5 entries, map[Blue:2 Red:2]",
+			stats.Dominant, stats.Entries, stats.Distribution)
+	}
+}
+
+func TestMoodStreaks(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		from, to string
+		entries  []string
+		want     []string
+	}{
+		{
+			// Clocks move forward on 2024-03-31 in Berlin.
+			name: "spring forward", loc: berlin, from: "2024-03-29", to: "2024-04-02",
+			entries: []string{"2024-03-30T10:00:00Z Red", "2024-03-30T23:30:00Z Red", "2024-04-01T10:00:00Z Red"},
+			want:    []string{"Red:2024-03-30..2024-04-01:3"},
+		},
+		{
+			// Clocks move back on 2024-10-27 in Berlin; 22:30 UTC is past
+			// midnight before the change, 23:30 UTC after it.
+			name: "fall back", loc: berlin, from: "2024-10-25", to: "2024-10-29",
+			entries: []string{"2024-10-26T22:30:00Z Blue", "2024-10-27T23:30:00Z Blue", "2024-10-28T12:00:00Z Green"},
+			want:    []string{"Blue:2024-10-27..2024-10-28:2"},
+		},
+		{
+			name: "fall back in New York", loc: newYork, from: "2024-11-02", to: "2024-11-04",
+			entries: []string{"2024-11-02T14:00:00Z Green", "2024-11-03T14:00:00Z Green", "2024-11-05T03:30:00Z Green"},
+			want:    []string{"Green:2024-11-02..2024-11-04:3"},
+		},
+		{
+			name: "a day without a mood ends a streak", loc: time.UTC, from: "2024-05-01", to: "2024-05-06",
+			entries: []string{
+				"2024-05-01T08:00:00Z Red", "2024-05-02T08:00:00Z Red", "2024-05-03T08:00:00Z",
+				"2024-05-04T08:00:00Z Red", "2024-05-05T08:00:00Z Red", "2024-05-05T09:00:00Z Blue",
+				"2024-05-06T08:00:00Z Red",
+			},
+			// 2024-05-05 is a tie between Blue and Red, so Blue.
+			want: []string{"Red:2024-05-01..2024-05-02:2"},
+		},
+		{
+			name: "single days are no streak", loc: time.UTC, from: "2024-05-01", to: "2024-05-03",
+			entries: []string{"2024-05-01T08:00:00Z Red", "2024-05-02T08:00:00Z Blue", "2024-05-03T08:00:00Z Red"},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		m := store.NewMemory()
+		insertMoods(t, m, tt.entries...)
+		from, _ := time.ParseInLocation("2006-01-02", tt.from, tt.loc)
+		to, _ := time.ParseInLocation("2006-01-02", tt.to, tt.loc)
+
+		stats, err := MoodStatistics(m, MoodQuery{UserID: testUser, From: from, To: to, Location: tt.loc, Period: PeriodDay})
+		if err != nil {
+			t.Fatalf("%s: MoodStatistics() error = %v", tt.name, err)
+		}
+		if got := streakSummary(stats.Streaks); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: streaks = %v, want %v", tt.name, got, tt.want)
+		}
+
+		lengths := make([]int, len(stats.Streaks))
+		for i, s := range stats.Streaks {
+			lengths[i] = s.Length
+		}
+		sort.Ints(lengths)
+		if len(lengths) == 0 && stats.Longest != nil || len(lengths) > 0 && (stats.Longest == nil || stats.Longest.Length != lengths[len(lengths)-1]) {
+			t.Errorf("%s: longest streak = %v, want the longest of %v", tt.name, stats.Longest, stats.Streaks)
+		}
+	}
+}
+
+func TestMoodStatisticsInvalidQuery(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []MoodQuery{
+		{From: from, To: from, Period: "year"},
+		{From: from, To: from.AddDate(0, 0, -1), Period: PeriodDay},
+		{From: from, To: from.AddDate(0, 0, MaxStatsDays[PeriodDay]), Period: PeriodDay},
+		{From: from, To: from.AddDate(0, 0, MaxStatsDays[PeriodMonth]), Period: PeriodMonth},
+	}
+
+	for _, q := range tests {
+		q.UserID, q.Location = testUser, time.UTC
+		if _, err := MoodStatistics(store.NewMemory(), q); err == nil {
+			t.Errorf("MoodStatistics(%s..%s by %s) succeeded, want an error", q.From.Format("2006-01-02"), q.To.Format("2006-01-02"), q.Period)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"journal-backend/moon"
+	"journal-backend/store"
+	"time"
+)
+
+// validateMoonEntry sets moon_sign to the sign the moon was in when the
+// entry was written. A sign sent by the client is replaced, so moon_sign
+// always follows created_at.
+func validateMoonEntry(e Entry) error {
+	entry := e.(*MoonEntry)
+
+	createdAt := store.ParseTime(entry.CreatedAt)
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	entry.MoonSign = moon.SignAt(createdAt)
+	return nil
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"journal-backend/store"
+)
+
+// MaxPageSize caps the limit a client may ask for.
+const MaxPageSize = 100
+
+// DefaultPageSize is used when a cursor is given without a limit.
+const DefaultPageSize = 50
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest asks for one page of a listing. The zero value asks for
+// everything at once.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+}
+
+// Page is one page of a listing. NextCursor is nil on the last page.
+type Page struct {
+	Entries    []Entry `json:"entries"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// EncodeCursor turns a position into the opaque string handed to clients.
+func EncodeCursor(cursor store.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor created by EncodeCursor.
+func DecodeCursor(s string) (*store.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor store.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || store.ParseTime(cursor.CreatedAt).IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// fetchPage runs q on the table of entryType for the page described by
+// page. One row more than asked for is fetched to find out whether there is
+// a next page.
+func fetchPage(entries store.EntryStore, entryType *EntryType, q store.Query, page PageRequest) (Page, error) {
+	if page.Cursor != "" {
+		cursor, err := DecodeCursor(page.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		q.After = cursor
+		if page.Limit == 0 {
+			page.Limit = DefaultPageSize
+		}
+	}
+	if page.Limit > MaxPageSize {
+		page.Limit = MaxPageSize
+	}
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
+	}
+
+	rows, err := entries.Select(q)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var result Page
+	if page.Limit > 0 && len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		next := EncodeCursor(store.RowCursor(rows[page.Limit-1]))
+		result.NextCursor = &next
+	}
+
+	result.Entries = make([]Entry, len(rows))
+	for i, row := range rows {
+		if result.Entries[i], err = entryType.FromRow(row); err != nil {
+			return Page{}, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"fmt"
+	"journal-backend/store"
+	"testing"
+	"time"
+)
+
+// pageThrough lists journal entries limit at a time and calls between after
+// every page but the last. It returns the ids in the order they were listed.
+func pageThrough(t *testing.T, m store.EntryStore, ascending bool, limit int, between func()) []int {
+	t.Helper()
+	journal, _ := EntryTypeByTable("journal_entries")
+
+	var ids []int
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("paging does not end")
+		}
+		page, err := ListEntries(journal, m, testUser, Filter{Ascending: ascending}, PageRequest{Limit: limit, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Entries) > limit {
+			t.Fatalf("page of %d entries, limit %d", len(page.Entries), limit)
+		}
+		for _, entry := range page.Entries {
+			ids = append(ids, entry.GetID())
+		}
+		if page.NextCursor == nil {
+			return ids
+		}
+		cursor = *page.NextCursor
+		between()
+	}
+}
+
+func TestPagingWithConcurrentInserts(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	at := func(minutes int) string { return base.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339) }
+
+	tests := []struct {
+		name      string
+		ascending bool
+		// insert returns the created_at of the row inserted after page n,
+		// for the first three pages.
+		insert func(n int) string
+		// want returns the expected ids given the ids of the inserted rows.
+		want func(inserted []int) []int
+	}{
+		{
+			name:   "newer rows while descending",
+			insert: func(n int) string { return at(100 + n) },
+			want:   func([]int) []int { return []int{7, 6, 5, 4, 3, 2, 1} },
+		},
+		{
+			name:   "older rows while descending",
+			insert: func(n int) string { return at(-100 - n) },
+			want:   func(inserted []int) []int { return append([]int{7, 6, 5, 4, 3, 2, 1}, inserted...) },
+		},
+		{
+			// Same created_at as the newest row, but a higher id: the rows
+			// come before it and so before every cursor.
+			name:   "ties while descending",
+			insert: func(int) string { return at(6) },
+			want:   func([]int) []int { return []int{7, 6, 5, 4, 3, 2, 1} },
+		},
+		{
+			name:      "newer rows while ascending",
+			ascending: true,
+			insert:    func(n int) string { return at(100 + n) },
+			want:      func(inserted []int) []int { return append([]int{1, 2, 3, 4, 5, 6, 7}, inserted...) },
+		},
+		{
+			name:      "older rows while ascending",
+			ascending: true,
+			insert:    func(n int) string { return at(-100 - n) },
+			want:      func([]int) []int { return []int{1, 2, 3, 4, 5, 6, 7} },
+		},
+	}
+
+	for _, tt := range tests {
+		for limit := 1; limit <= 4; limit++ {
+			m := store.NewMemory()
+			for i := 0; i < 7; i++ {
+				insertRow(t, m, "journal_entries", testUser, at(i))
+			}
+
+			var inserted []int
+			got := pageThrough(t, m, tt.ascending, limit, func() {
+				if len(inserted) < 3 {
+					inserted = append(inserted, insertRow(t, m, "journal_entries", testUser, tt.insert(len(inserted))))
+				}
+			})
+
+			if want := tt.want(inserted); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s, limit %d: ids = %v, want %v", tt.name, limit, got, want)
+			}
+		}
+	}
+}
+
+func TestNextCursorEndsOnLastPage(t *testing.T) {
+	journal, _ := EntryTypeByTable("journal_entries")
+	m := store.NewMemory()
+	for i := 0; i < 4; i++ {
+		insertRow(t, m, "journal_entries", testUser, fmt.Sprintf("2024-05-0%dT08:00:00Z", i+1))
+	}
+
+	tests := []struct {
+		limit    int
+		wantNext bool
+	}{
+		{limit: 0, wantNext: false},
+		{limit: 3, wantNext: true},
+		{limit: 4, wantNext: false},
+		{limit: 5, wantNext: false},
+	}
+
+	for _, tt := range tests {
+		page, err := ListEntries(journal, m, testUser, Filter{}, PageRequest{Limit: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := page.NextCursor != nil; got != tt.wantNext {
+			t.Errorf("limit %d: next cursor %v, want %v", tt.limit, got, tt.wantNext)
+		}
+	}
+
+	// A cursor at the last row leads to an empty last page.
+	last := EncodeCursor(store.Cursor{CreatedAt: "2024-05-01T08:00:00Z", ID: 1})
+	page, err := ListEntries(journal, m, testUser, Filter{}, PageRequest{Limit: 2, Cursor: last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 0 || page.NextCursor != nil {
+		t.Errorf("page after the last row = %d entries, next cursor %v, want none", len(page.Entries), page.NextCursor)
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	journal, _ := EntryTypeByTable("journal_entries")
+	m := store.NewMemory()
+	insertRow(t, m, "journal_entries", testUser, "2024-05-01T08:00:00Z")
+
+	for _, cursor := range []string{
+		"not base64!",
+		"e30",         // {}
+		"bm90IGpzb24", // not json
+		EncodeCursor(store.Cursor{CreatedAt: "yesterday", ID: 1}),
+	} {
+		if _, err := DecodeCursor(cursor); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", cursor, err)
+		}
+		if _, err := ListEntries(journal, m, testUser, Filter{}, PageRequest{Cursor: cursor}); err != ErrInvalidCursor {
+			t.Errorf("ListEntries() with cursor %q = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+
+	valid := store.Cursor{CreatedAt: "2024-05-01T08:00:00Z", ID: 3}
+	if got, err := DecodeCursor(EncodeCursor(valid)); err != nil || *got != valid {
+		t.Errorf("DecodeCursor(EncodeCursor(%v)) = %v, %v", valid, got, err)
+	}
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/json"
+	"journal-backend/moon"
+)
+
+// Entry is implemented by the structs of all entry types.
+type Entry interface {
+	GetID() int
+	GetCreatedAt() string
+	SetUserID(userID string)
+	SetCreatedAt(createdAt string)
+	SetTimezone(timezone string)
+}
+
+// EntryType describes one kind of journal entry. All entry handlers work
+// through the registry, so a new kind only needs to be added to EntryTypes.
+type EntryType struct {
+	// Name is used in messages to the client.
+	Name string
+	// Table holds the rows of this type.
+	Table string
+	// Path is the REST resource of this type, e.g. /journal-entries.
+	Path string
+	// Index is the selected_index that lists this type on GET /entries.
+	Index int
+	// Columns are selected when entries of this type are listed.
+	Columns string
+	// Filters are the columns listings of this type may be filtered by.
+	Filters []string
+	// SearchFields are the columns full-text search looks at.
+	SearchFields []string
+	// Rules declare what the fields of a payload may contain.
+	Rules []Rule
+	// New returns a pointer to an empty entry struct.
+	New func() Entry
+	// Validate checks a new entry before it is written and may fill in
+	// fields derived from others. It may be nil.
+	Validate func(Entry) error
+	// Derived are the fields Validate computes from fields that never
+	// change. Updates leave them alone.
+	Derived []string
+}
+
+// intentionList is the schema of let_go and want: a list of short texts.
+var intentionList = &Schema{
+	Type:     TypeArray,
+	MaxItems: 50,
+	Items:    &Schema{Type: TypeString, MaxLength: 500},
+}
+
+var EntryTypes = []*EntryType{
+	{
+		Name:         "journal entry",
+		Table:        "journal_entries",
+		Path:         "journal-entries",
+		Index:        0,
+		Columns:      "id,user_id,content,content_grateful,content_proud,emotion_color,created_at,timezone",
+		Filters:      []string{"emotion_color"},
+		SearchFields: []string{"content", "content_grateful", "content_proud"},
+		Rules: []Rule{
+			{Field: "content", Required: true, MaxLength: 10000},
+			{Field: "content_grateful", MaxLength: 2000},
+			{Field: "content_proud", MaxLength: 2000},
+			{Field: "emotion_color", OneOf: EmotionColors},
+		},
+		New: func() Entry { return &PersonalEntry{} },
+	},
+	{
+		Name:         "moon entry",
+		Table:        "moon_entries",
+		Path:         "moon-entries",
+		Index:        1,
+		Columns:      "id,user_id,let_go,want,moon_sign,created_at,timezone",
+		Filters:      []string{"moon_sign"},
+		SearchFields: []string{"let_go", "want"},
+		Rules: []Rule{
+			{Field: "let_go", Schema: intentionList},
+			{Field: "want", Schema: intentionList},
+			{Field: "moon_sign", OneOf: moon.Signs},
+		},
+		New:      func() Entry { return &MoonEntry{} },
+		Validate: validateMoonEntry,
+		Derived:  []string{"moon_sign"},
+	},
+	{
+		Name:         "relationship check",
+		Table:        "relationship_check",
+		Path:         "relationship-checks",
+		Index:        2,
+		Columns:      "id,user_id,question,answer,created_at,timezone",
+		SearchFields: []string{"question", "answer"},
+		Rules: []Rule{
+			{Field: "question", Required: true, MaxLength: 1000},
+			{Field: "answer", Required: true, MaxLength: 5000},
+		},
+		New: func() Entry { return &RelationshipCheckEntry{} },
+	},
+}
+
+// EntryTypeByTable returns the entry type stored in table.
+func EntryTypeByTable(table string) (*EntryType, bool) {
+	for _, t := range EntryTypes {
+		if t.Table == table {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// EntryTypeByIndex returns the entry type listed for selected_index.
+func EntryTypeByIndex(index int) (*EntryType, bool) {
+	for _, t := range EntryTypes {
+		if t.Index == index {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Decode parses a request body into a new entry of this type.
+func (t *EntryType) Decode(raw map[string]interface{}) (Entry, error) {
+	entry := t.New()
+
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonBytes, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// FromRow decodes a row read from the store into a new entry of this type,
+// with created_at in the format of FormatTimestamp.
+func (t *EntryType) FromRow(row map[string]interface{}) (Entry, error) {
+	entry, err := t.Decode(row)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.SetCreatedAt(FormatTimestamp(entry.GetCreatedAt()))
+	return entry, nil
+}
+
+// Check runs the validation of this type, if there is one.
+func (t *EntryType) Check(entry Entry) error {
+	if t.Validate == nil {
+		return nil
+	}
+	return t.Validate(entry)
+}
+
+// Prepare decodes a request body and sets the fields the server controls:
+// the owner, and for new entries the creation time and the timezone of the
+// writer.
+func (t *EntryType) Prepare(raw map[string]interface{}, userID, createdAt, timezone string) (Entry, error) {
+	entry, err := t.Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.SetUserID(userID)
+	if createdAt != "" {
+		entry.SetCreatedAt(createdAt)
+	}
+	if timezone != "" {
+		entry.SetTimezone(timezone)
+	}
+
+	return entry, nil
+}
+
+func (e *PersonalEntry) GetID() int                    { return e.EntryID }
+func (e *PersonalEntry) GetCreatedAt() string          { return e.CreatedAt }
+func (e *PersonalEntry) SetUserID(userID string)       { e.UserId = userID }
+func (e *PersonalEntry) SetCreatedAt(createdAt string) { e.CreatedAt = createdAt }
+func (e *PersonalEntry) SetTimezone(timezone string)   { e.Timezone = timezone }
+
+func (e *MoonEntry) GetID() int                    { return e.EntryID }
+func (e *MoonEntry) GetCreatedAt() string          { return e.CreatedAt }
+func (e *MoonEntry) SetUserID(userID string)       { e.UserId = userID }
+func (e *MoonEntry) SetCreatedAt(createdAt string) { e.CreatedAt = createdAt }
+func (e *MoonEntry) SetTimezone(timezone string)   { e.Timezone = timezone }
+
+func (e *RelationshipCheckEntry) GetID() int                    { return e.EntryID }
+func (e *RelationshipCheckEntry) GetCreatedAt() string          { return e.CreatedAt }
+func (e *RelationshipCheckEntry) SetUserID(userID string)       { e.UserId = userID }
+func (e *RelationshipCheckEntry) SetCreatedAt(createdAt string) { e.CreatedAt = createdAt }
+func (e *RelationshipCheckEntry) SetTimezone(timezone string)   { e.Timezone = timezone }
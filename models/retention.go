@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"journal-backend/store"
+	"sort"
+	"time"
+)
+
+// Let-go policy actions.
+const (
+	LetGoDelete  = "delete"
+	LetGoArchive = "archive"
+)
+
+const (
+	// DefaultLetGoExpiry applies to users without a policy of their own.
+	DefaultLetGoExpiry = 24 * time.Hour
+	// MoonCycleHours is one synodic month, rounded up to whole hours.
+	MoonCycleHours = 709
+	// MaxLetGoExpiryHours is the longest expiry a user can choose.
+	MaxLetGoExpiryHours = 366 * 24
+)
+
+var ErrInvalidPolicy = errors.New("invalid let-go policy")
+
+// LetGoPolicy is the let-go retention of a user as the API shows it.
+type LetGoPolicy struct {
+	ExpireAfterHours int    `json:"expire_after_hours"`
+	Action           string `json:"action"`
+	// Default is set if the user has not chosen a policy.
+	Default bool `json:"default"`
+}
+
+// GetLetGoPolicy returns the let-go policy of userID, or the default one.
+func GetLetGoPolicy(profiles store.ProfileStore, userID string) (LetGoPolicy, error) {
+	policy, err := profiles.GetLetGoPolicy(userID)
+	if err != nil {
+		return LetGoPolicy{}, err
+	}
+	if policy == nil {
+		return LetGoPolicy{
+			ExpireAfterHours: int(DefaultLetGoExpiry / time.Hour),
+			Action:           LetGoDelete,
+			Default:          true,
+		}, nil
+	}
+
+	action := LetGoDelete
+	if policy.Archive {
+		action = LetGoArchive
+	}
+	return LetGoPolicy{ExpireAfterHours: int(policy.ExpireAfter / time.Hour), Action: action}, nil
+}
+
+// SetLetGoPolicy validates policy and stores it for userID.
+func SetLetGoPolicy(profiles store.ProfileStore, userID string, policy LetGoPolicy) error {
+	if policy.ExpireAfterHours < 1 || policy.ExpireAfterHours > MaxLetGoExpiryHours {
+		return fmt.Errorf("%w: expire_after_hours must be between 1 and %d", ErrInvalidPolicy, MaxLetGoExpiryHours)
+	}
+	if policy.Action != LetGoDelete && policy.Action != LetGoArchive {
+		return fmt.Errorf("%w: action must be %s or %s", ErrInvalidPolicy, LetGoDelete, LetGoArchive)
+	}
+
+	return profiles.SetLetGoPolicy(userID, &store.LetGoPolicy{
+		UserID:      userID,
+		ExpireAfter: time.Duration(policy.ExpireAfterHours) * time.Hour,
+		Archive:     policy.Action == LetGoArchive,
+	})
+}
+
+// ResetLetGoPolicy makes userID use the default policy again.
+func ResetLetGoPolicy(profiles store.ProfileStore, userID string) error {
+	return profiles.SetLetGoPolicy(userID, nil)
+}
+
+// ExpireLetGo removes the let-go items that have outlived the policy of
+// their user and returns the audit records of everything removed. Users
+// sharing a policy are handled in one pass.
+func ExpireLetGo(s store.Store, now time.Time) ([]store.ExpiredLetGo, error) {
+	policies, err := s.LetGoPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	type rule struct {
+		expireAfter time.Duration
+		archive     bool
+	}
+	groups := make(map[rule][]string)
+	var custom []string
+	for _, p := range policies {
+		r := rule{expireAfter: p.ExpireAfter, archive: p.Archive}
+		groups[r] = append(groups[r], p.UserID)
+		custom = append(custom, p.UserID)
+	}
+
+	expired, err := s.ExpireLetGo(store.LetGoExpiry{
+		Before:        now.Add(-DefaultLetGoExpiry),
+		ExceptUserIDs: custom,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]rule, 0, len(groups))
+	for r := range groups {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].expireAfter != rules[j].expireAfter {
+			return rules[i].expireAfter < rules[j].expireAfter
+		}
+		return !rules[i].archive
+	})
+
+	for _, r := range rules {
+		removed, err := s.ExpireLetGo(store.LetGoExpiry{
+			Before:  now.Add(-r.expireAfter),
+			Archive: r.archive,
+			UserIDs: groups[r],
+		})
+		if err != nil {
+			return expired, err
+		}
+		expired = append(expired, removed...)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journal-backend/search"
+	"journal-backend/store"
+	"sort"
+	"strings"
+)
+
+// DefaultSearchLimit is the number of results returned when no limit is given.
+const DefaultSearchLimit = 20
+
+var ErrEmptySearch = errors.New("search query contains no words")
+
+// SearchResult is one entry matching a search, with the matching words of
+// Snippet wrapped in <mark></mark>. The rest of Snippet is HTML-escaped.
+type SearchResult struct {
+	Type      string  `json:"type"`
+	ID        int     `json:"id"`
+	CreatedAt string  `json:"created_at"`
+	Rank      float64 `json:"rank"`
+	Snippet   string  `json:"snippet"`
+}
+
+// Search finds the entries of userID matching text in the SearchFields of
+// the given entry types, best matches first; at most limit of them, capped
+// at MaxPageSize. Stores with their own full-text search are asked
+// directly, all others are searched in process.
+func Search(entries store.EntryStore, userID, text string, entryTypes []*EntryType, limit int) ([]SearchResult, error) {
+	terms := search.Terms(text)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearch
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	q := store.SearchQuery{UserID: userID, Terms: terms, Limit: limit}
+	for _, t := range entryTypes {
+		q.Tables = append(q.Tables, store.SearchTable{Table: t.Table, Fields: t.SearchFields})
+	}
+
+	var hits []store.SearchHit
+	var err error
+	if searcher, ok := entries.(store.Searcher); ok {
+		hits, err = searcher.Search(q)
+	} else {
+		hits, err = searchInProcess(entries, q)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = SearchResult{
+			Type:      hit.Table,
+			ID:        hit.ID,
+			CreatedAt: FormatTimestamp(hit.CreatedAt),
+			Rank:      hit.Rank,
+			Snippet:   hit.Snippet,
+		}
+	}
+	return results, nil
+}
+
+// searchInProcess loads all entries of the user and ranks them with an
+// in-memory index.
+func searchInProcess(entries store.EntryStore, q store.SearchQuery) ([]store.SearchHit, error) {
+	index := search.NewIndex()
+	rows := make(map[string]map[string]interface{})
+
+	for _, t := range q.Tables {
+		result, err := entries.Select(store.Query{
+			Table:   t.Table,
+			Columns: "id,created_at," + strings.Join(t.Fields, ","),
+			UserID:  q.UserID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range result {
+			key := fmt.Sprintf("%s/%d", t.Table, store.RowCursor(row).ID)
+			rows[key] = row
+
+			fields := make(map[string]string, len(t.Fields))
+			for _, field := range t.Fields {
+				fields[field] = searchText(row[field])
+			}
+			index.Add(search.Document{Key: key, Fields: fields})
+		}
+	}
+
+	var hits []store.SearchHit
+	for _, hit := range index.Search(strings.Join(q.Terms, " "), q.Limit) {
+		table, _, _ := strings.Cut(hit.Key, "/")
+		cursor := store.RowCursor(rows[hit.Key])
+		hits = append(hits, store.SearchHit{
+			Table:     table,
+			ID:        cursor.ID,
+			CreatedAt: cursor.CreatedAt,
+			Rank:      hit.Score,
+			Snippet:   hit.Snippet,
+		})
+	}
+
+	return hits, nil
+}
+
+// searchText returns the searchable text of a column value. For JSON
+// payloads like let_go and want only the string values count, not the keys.
+func searchText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.RawMessage:
+		var decoded interface{}
+		if err := json.Unmarshal(v, &decoded); err != nil {
+			return ""
+		}
+		return searchText(decoded)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, searchText(item))
+		}
+		return strings.Join(parts, " ")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(v))
+		for _, k := range keys {
+			parts = append(parts, searchText(v[k]))
+		}
+		return strings.Join(parts, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
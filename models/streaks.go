@@ -0,0 +1,242 @@
+package models
+
+import (
+	"fmt"
+	"journal-backend/store"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHeatmapDays is the length of the heatmap when no from is given.
+const DefaultHeatmapDays = 365
+
+// MaxHeatmapDays limits the range of the heatmap.
+const MaxHeatmapDays = 366
+
+// StreakCacheTTL is how long the loaded entries of a user are used before
+// they are read again. This picks up entries written past the service, and
+// users who stopped asking for streaks are dropped.
+const StreakCacheTTL = 30 * time.Minute
+
+// HeatmapDay is a day with at least one entry.
+type HeatmapDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// Streaks describes how regularly a user writes entries of any type.
+type Streaks struct {
+	Timezone     string       `json:"timezone"`
+	Current      int          `json:"current_streak"`
+	CurrentStart *string      `json:"current_start"`
+	Longest      int          `json:"longest_streak"`
+	LongestStart *string      `json:"longest_start"`
+	LongestEnd   *string      `json:"longest_end"`
+	ActiveToday  bool         `json:"active_today"`
+	Heatmap      []HeatmapDay `json:"heatmap"`
+}
+
+// StreakService keeps the creation times of every user's entries in memory,
+// so streaks are computed without reading all entries again. The times of a
+// user are loaded on the first request and kept up to date by Record until
+// they are older than StreakCacheTTL.
+type StreakService struct {
+	mu    sync.Mutex
+	users map[string]*activity
+	now   func() time.Time
+}
+
+// activity holds the created_at of the entries of one user by table and id.
+// Records that arrive while the entries are being loaded are kept and
+// merged into the loaded ones.
+type activity struct {
+	ready    bool
+	loadedAt time.Time
+	entries  map[string]string
+}
+
+// NewStreakService creates an empty StreakService.
+func NewStreakService() *StreakService {
+	return &StreakService{users: make(map[string]*activity), now: time.Now}
+}
+
+// Record adds a new entry of userID. Users whose entries were never loaded
+// are skipped; their entries are read when they first ask for streaks.
+func (s *StreakService) Record(userID, table string, id int, createdAt string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.users[userID]; ok {
+		a.entries[activityKey(table, id)] = createdAt
+	}
+}
+
+// Forget drops what is known about userID, e.g. after an entry was deleted.
+func (s *StreakService) Forget(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, userID)
+}
+
+// Streaks returns the current and longest streak of days on which userID
+// wrote an entry, counted in loc, and the active days between from and to.
+// The current streak is still running if the last entry was yesterday.
+func (s *StreakService) Streaks(entries store.EntryStore, userID string, loc *time.Location, from, to time.Time) (Streaks, error) {
+	if to.Before(from) {
+		return Streaks{}, fmt.Errorf("%w: from is after to", ErrInvalidStatsQuery)
+	}
+	if rangeDays(from, to) > MaxHeatmapDays {
+		return Streaks{}, fmt.Errorf("%w: the heatmap covers at most %d days", ErrInvalidStatsQuery, MaxHeatmapDays)
+	}
+
+	times, err := s.createdAt(entries, userID)
+	if err != nil {
+		return Streaks{}, err
+	}
+
+	counts := make(map[string]int)
+	for _, createdAt := range times {
+		if day := LocalDay(createdAt, loc); !day.IsZero() {
+			counts[day.Format("2006-01-02")]++
+		}
+	}
+
+	days := make([]string, 0, len(counts))
+	for day := range counts {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	result := Streaks{Timezone: loc.String(), Heatmap: []HeatmapDay{}}
+	for _, day := range days {
+		if day >= from.Format("2006-01-02") && day <= to.Format("2006-01-02") {
+			result.Heatmap = append(result.Heatmap, HeatmapDay{Date: day, Count: counts[day]})
+		}
+	}
+
+	now := s.now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	yesterday := today.AddDate(0, 0, -1).Format("2006-01-02")
+	result.ActiveToday = counts[today.Format("2006-01-02")] > 0
+
+	runStart, runLength := "", 0
+	for i, day := range days {
+		if i > 0 && nextDay(days[i-1], loc) == day {
+			runLength++
+		} else {
+			runStart, runLength = day, 1
+		}
+
+		if runLength > result.Longest {
+			start, end := runStart, day
+			result.Longest, result.LongestStart, result.LongestEnd = runLength, &start, &end
+		}
+		if i == len(days)-1 && (day == today.Format("2006-01-02") || day == yesterday) {
+			start := runStart
+			result.Current, result.CurrentStart = runLength, &start
+		}
+	}
+
+	return result, nil
+}
+
+// createdAt returns the creation times of all entries of userID, loading
+// them from entries if they are not known yet.
+func (s *StreakService) createdAt(entries store.EntryStore, userID string) ([]string, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	a, ok := s.users[userID]
+	if ok && a.ready && !a.expired(now) {
+		times := a.times()
+		s.mu.Unlock()
+		return times, nil
+	}
+	if !ok || a.ready {
+		s.evictExpired(now)
+		a = &activity{entries: make(map[string]string)}
+		s.users[userID] = a
+	}
+	s.mu.Unlock()
+
+	loaded, err := loadActivity(entries, userID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		if s.users[userID] == a {
+			delete(s.users, userID)
+		}
+		return nil, err
+	}
+
+	for key, createdAt := range a.entries {
+		loaded[key] = createdAt
+	}
+	a.entries = loaded
+	// A Forget while loading means the loaded entries may be outdated, so
+	// they are used for this request only.
+	if s.users[userID] == a {
+		a.ready, a.loadedAt = true, now
+	}
+
+	return a.times(), nil
+}
+
+// evictExpired drops the users whose entries were loaded more than
+// StreakCacheTTL before now. The caller must hold the lock.
+func (s *StreakService) evictExpired(now time.Time) {
+	for userID, a := range s.users {
+		if a.ready && a.expired(now) {
+			delete(s.users, userID)
+		}
+	}
+}
+
+func (a *activity) expired(now time.Time) bool {
+	return now.Sub(a.loadedAt) >= StreakCacheTTL
+}
+
+func (a *activity) times() []string {
+	times := make([]string, 0, len(a.entries))
+	for _, createdAt := range a.entries {
+		times = append(times, createdAt)
+	}
+	return times
+}
+
+// loadActivity reads the creation times of the entries of all types of
+// userID.
+func loadActivity(entries store.EntryStore, userID string) (map[string]string, error) {
+	loaded := make(map[string]string)
+
+	for _, entryType := range EntryTypes {
+		rows, err := entries.Select(store.Query{
+			Table:   entryType.Table,
+			Columns: "id, created_at",
+			UserID:  userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			cursor := store.RowCursor(row)
+			loaded[activityKey(entryType.Table, cursor.ID)] = cursor.CreatedAt
+		}
+	}
+
+	return loaded, nil
+}
+
+func activityKey(table string, id int) string {
+	return fmt.Sprintf("%s/%d", table, id)
+}
+
+func nextDay(day string, loc *time.Location) string {
+	t, _ := time.ParseInLocation("2006-01-02", day, loc)
+	return t.AddDate(0, 0, 1).Format("2006-01-02")
+}
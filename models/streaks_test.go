@@ -0,0 +1,234 @@
+package models
+
+import (
+	"fmt"
+	"journal-backend/store"
+	"testing"
+	"time"
+)
+
+func TestStreaks(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	day := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		loc        *time.Location
+		createdAt  []string
+		from, to   string
+		want       Streaks
+		wantDays   []string
+		wantCounts []int
+	}{
+		{
+			name:      "no entries",
+			loc:       time.UTC,
+			createdAt: nil,
+			want:      Streaks{},
+		},
+		{
+			name: "current streak ends today",
+			loc:  time.UTC,
+			createdAt: []string{
+				"2024-05-01T08:00:00Z", "2024-05-02T08:00:00Z", "2024-05-03T08:00:00Z",
+				"2024-05-06T08:00:00Z",
+				"2024-05-09T08:00:00Z", "2024-05-10T08:00:00Z", "2024-05-10T09:00:00Z",
+			},
+			want: Streaks{
+				Current: 2, CurrentStart: day("2024-05-09"),
+				Longest: 3, LongestStart: day("2024-05-01"), LongestEnd: day("2024-05-03"),
+				ActiveToday: true,
+			},
+			wantDays:   []string{"2024-05-01", "2024-05-02", "2024-05-03", "2024-05-06", "2024-05-09", "2024-05-10"},
+			wantCounts: []int{1, 1, 1, 1, 1, 2},
+		},
+		{
+			name:      "current streak still running from yesterday",
+			loc:       time.UTC,
+			createdAt: []string{"2024-05-08T08:00:00Z", "2024-05-09T08:00:00Z"},
+			want: Streaks{
+				Current: 2, CurrentStart: day("2024-05-08"),
+				Longest: 2, LongestStart: day("2024-05-08"), LongestEnd: day("2024-05-09"),
+			},
+			wantDays:   []string{"2024-05-08", "2024-05-09"},
+			wantCounts: []int{1, 1},
+		},
+		{
+			name:      "current streak broken",
+			loc:       time.UTC,
+			createdAt: []string{"2024-05-07T08:00:00Z", "2024-05-08T08:00:00Z"},
+			want: Streaks{
+				Longest: 2, LongestStart: day("2024-05-07"), LongestEnd: day("2024-05-08"),
+			},
+			wantDays:   []string{"2024-05-07", "2024-05-08"},
+			wantCounts: []int{1, 1},
+		},
+		{
+			name:      "the earlier of two equal runs is the longest",
+			loc:       time.UTC,
+			createdAt: []string{"2024-04-01T08:00:00Z", "2024-04-02T08:00:00Z", "2024-04-05T08:00:00Z", "2024-04-06T08:00:00Z"},
+			want: Streaks{
+				Longest: 2, LongestStart: day("2024-04-01"), LongestEnd: day("2024-04-02"),
+			},
+			wantDays:   []string{"2024-04-01", "2024-04-02", "2024-04-05", "2024-04-06"},
+			wantCounts: []int{1, 1, 1, 1},
+		},
+		{
+			// 23:30 UTC is the next day in Berlin.
+			name:      "days are counted in loc",
+			loc:       berlin,
+			createdAt: []string{"2024-05-08T23:30:00Z", "2024-05-09T08:00:00Z"},
+			want: Streaks{
+				Current: 1, CurrentStart: day("2024-05-09"),
+				Longest: 1, LongestStart: day("2024-05-09"), LongestEnd: day("2024-05-09"),
+			},
+			wantDays:   []string{"2024-05-09"},
+			wantCounts: []int{2},
+		},
+		{
+			// The streak runs through the change to summer time.
+			name:      "streak across a DST change",
+			loc:       berlin,
+			createdAt: []string{"2024-03-30T10:00:00Z", "2024-03-31T10:00:00Z", "2024-04-01T10:00:00Z"},
+			want: Streaks{
+				Longest: 3, LongestStart: day("2024-03-30"), LongestEnd: day("2024-04-01"),
+			},
+			wantDays:   []string{"2024-03-30", "2024-03-31", "2024-04-01"},
+			wantCounts: []int{1, 1, 1},
+		},
+		{
+			name:      "heatmap limited to from and to",
+			loc:       time.UTC,
+			createdAt: []string{"2024-04-01T08:00:00Z", "2024-04-02T08:00:00Z", "2024-04-03T08:00:00Z"},
+			from:      "2024-04-02",
+			to:        "2024-04-02",
+			want: Streaks{
+				Longest: 3, LongestStart: day("2024-04-01"), LongestEnd: day("2024-04-03"),
+			},
+			wantDays:   []string{"2024-04-02"},
+			wantCounts: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		m := store.NewMemory()
+		for i, createdAt := range tt.createdAt {
+			insertRow(t, m, EntryTypes[i%len(EntryTypes)].Table, testUser, createdAt)
+		}
+		insertRow(t, m, "journal_entries", "someone else", "2024-05-10T08:00:00Z")
+
+		s := NewStreakService()
+		s.now = func() time.Time { return now }
+
+		from, to := now.AddDate(0, 0, -DefaultHeatmapDays), now
+		if tt.from != "" {
+			from, _ = time.ParseInLocation("2006-01-02", tt.from, tt.loc)
+			to, _ = time.ParseInLocation("2006-01-02", tt.to, tt.loc)
+		}
+
+		got, err := s.Streaks(m, testUser, tt.loc, from, to)
+		if err != nil {
+			t.Fatalf("%s: Streaks() error = %v", tt.name, err)
+		}
+
+		if got.Timezone != tt.loc.String() {
+			t.Errorf("%s: Timezone = %q, want %q", tt.name, got.Timezone, tt.loc)
+		}
+		if got.Current != tt.want.Current || str(got.CurrentStart) != str(tt.want.CurrentStart) {
+			t.Errorf("%s: current = %d from %s, want %d from %s", tt.name, got.Current, str(got.CurrentStart), tt.want.Current, str(tt.want.CurrentStart))
+		}
+		if got.Longest != tt.want.Longest || str(got.LongestStart) != str(tt.want.LongestStart) || str(got.LongestEnd) != str(tt.want.LongestEnd) {
+			t.Errorf("%s: longest = %d from %s to %s, want %d from %s to %s", tt.name,
+				got.Longest, str(got.LongestStart), str(got.LongestEnd),
+				tt.want.Longest, str(tt.want.LongestStart), str(tt.want.LongestEnd))
+		}
+		if got.ActiveToday != tt.want.ActiveToday {
+			t.Errorf("%s: ActiveToday = %v, want %v", tt.name, got.ActiveToday, tt.want.ActiveToday)
+		}
+
+		var days []string
+		var counts []int
+		for _, d := range got.Heatmap {
+			days, counts = append(days, d.Date), append(counts, d.Count)
+		}
+		if fmt.Sprint(days, counts) != fmt.Sprint(tt.wantDays, tt.wantCounts) {
+			t.Errorf("%s: heatmap = %v %v, want %v %v", tt.name, days, counts, tt.wantDays, tt.wantCounts)
+		}
+	}
+}
+
+func TestStreaksInvalidRange(t *testing.T) {
+	s := NewStreakService()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, to := range []time.Time{from.AddDate(0, 0, -1), from.AddDate(0, 0, MaxHeatmapDays)} {
+		if _, err := s.Streaks(store.NewMemory(), testUser, time.UTC, from, to); err == nil {
+			t.Errorf("Streaks(%s, %s) succeeded, want an error", from.Format("2006-01-02"), to.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestStreakServiceCache(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	from := now.AddDate(0, 0, -30)
+
+	m := store.NewMemory()
+	insertRow(t, m, "journal_entries", testUser, "2024-05-10T08:00:00Z")
+
+	s := NewStreakService()
+	s.now = func() time.Time { return now }
+	activeDays := func(userID string) int {
+		t.Helper()
+		got, err := s.Streaks(m, userID, time.UTC, from, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(got.Heatmap)
+	}
+
+	if got := activeDays(testUser); got != 1 {
+		t.Fatalf("active days = %d, want 1", got)
+	}
+
+	// Entries recorded after loading are counted, others only once the
+	// loaded entries expire.
+	id := insertRow(t, m, "moon_entries", testUser, "2024-05-09T08:00:00Z")
+	s.Record(testUser, "moon_entries", id, "2024-05-09T08:00:00Z")
+	insertRow(t, m, "journal_entries", testUser, "2024-05-08T08:00:00Z")
+	if got := activeDays(testUser); got != 2 {
+		t.Errorf("active days after Record = %d, want 2", got)
+	}
+
+	now = now.Add(StreakCacheTTL)
+	if got := activeDays(testUser); got != 3 {
+		t.Errorf("active days after the TTL = %d, want 3", got)
+	}
+
+	// Forget makes the next request read the entries again.
+	insertRow(t, m, "journal_entries", testUser, "2024-05-07T08:00:00Z")
+	s.Forget(testUser)
+	if got := activeDays(testUser); got != 4 {
+		t.Errorf("active days after Forget = %d, want 4", got)
+	}
+
+	// Users who stopped asking are dropped when another user is loaded.
+	now = now.Add(StreakCacheTTL)
+	activeDays("someone else")
+	if _, ok := s.users[testUser]; ok {
+		t.Error("expired user was not evicted")
+	}
+	if len(s.users) != 1 {
+		t.Errorf("%d users cached, want 1", len(s.users))
+	}
+}
+
+func str(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"journal-backend/store"
+	"math"
+	"sort"
+)
+
+// timelineCursor is a position in the timeline. Entries of different types
+// created at the same time are ordered by their position in EntryTypes.
+type timelineCursor struct {
+	CreatedAt string `json:"c"`
+	Table     string `json:"t"`
+	ID        int    `json:"i"`
+}
+
+// TimelineEntry is an entry of the timeline. It is answered as the JSON of
+// the entry with its table in the "type" field added in front.
+type TimelineEntry struct {
+	Type  string
+	Entry Entry
+}
+
+func (e *TimelineEntry) GetID() int                    { return e.Entry.GetID() }
+func (e *TimelineEntry) GetCreatedAt() string          { return e.Entry.GetCreatedAt() }
+func (e *TimelineEntry) SetUserID(userID string)       { e.Entry.SetUserID(userID) }
+func (e *TimelineEntry) SetCreatedAt(createdAt string) { e.Entry.SetCreatedAt(createdAt) }
+func (e *TimelineEntry) SetTimezone(timezone string)   { e.Entry.SetTimezone(timezone) }
+
+func (e *TimelineEntry) MarshalJSON() ([]byte, error) {
+	entry, err := json.Marshal(e.Entry)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := json.Marshal(e.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	data := append([]byte(`{"type":`), typ...)
+	if len(entry) > 2 {
+		data = append(data, ',')
+	}
+	return append(data, entry[1:]...), nil
+}
+
+// Timeline returns the entries of all types written by userID interleaved
+// by created_at as TimelineEntry values.
+func Timeline(entries store.EntryStore, userID string, filter Filter, page PageRequest) (Page, error) {
+	if len(filter.Equal) > 0 {
+		return Page{}, fmt.Errorf("%w: the timeline can only be filtered by from and to", ErrInvalidFilter)
+	}
+
+	var after *timelineCursor
+	if page.Cursor != "" {
+		cursor, err := decodeTimelineCursor(page.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		after = cursor
+		if page.Limit == 0 {
+			page.Limit = DefaultPageSize
+		}
+	}
+	if page.Limit > MaxPageSize {
+		page.Limit = MaxPageSize
+	}
+
+	position := make(map[string]int, len(EntryTypes))
+	for i, entryType := range EntryTypes {
+		position[entryType.Table] = i
+	}
+
+	var rows []map[string]interface{}
+	for i, entryType := range EntryTypes {
+		q := store.Query{Table: entryType.Table, Columns: entryType.Columns, UserID: userID}
+		if err := filter.applyRange(&q); err != nil {
+			return Page{}, err
+		}
+		if page.Limit > 0 {
+			q.Limit = page.Limit + 1
+		}
+		if after != nil {
+			q.After = typeCursor(after, i, position[after.Table], filter.Ascending)
+		}
+
+		result, err := entries.Select(q)
+		if err != nil {
+			return Page{}, err
+		}
+		for _, row := range result {
+			row["type"] = entryType.Table
+			rows = append(rows, row)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := store.RowCursor(rows[i]), store.RowCursor(rows[j])
+		ta, tb := store.ParseTime(a.CreatedAt), store.ParseTime(b.CreatedAt)
+		if !ta.Equal(tb) {
+			return ta.After(tb) != filter.Ascending
+		}
+		pa, pb := position[rows[i]["type"].(string)], position[rows[j]["type"].(string)]
+		if pa != pb {
+			return (pa < pb) != filter.Ascending
+		}
+		return (a.ID > b.ID) != filter.Ascending
+	})
+
+	var result Page
+	if page.Limit > 0 && len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		next := encodeTimelineCursor(rows[page.Limit-1])
+		result.NextCursor = &next
+	}
+
+	result.Entries = make([]Entry, len(rows))
+	for i, row := range rows {
+		table := row["type"].(string)
+		entryType, _ := EntryTypeByTable(table)
+		entry, err := entryType.FromRow(row)
+		if err != nil {
+			return Page{}, err
+		}
+		result.Entries[i] = &TimelineEntry{Type: table, Entry: entry}
+	}
+
+	return result, nil
+}
+
+// typeCursor translates a timeline position into a position in the table of
+// the entry type at index. Rows created at the cursor time are included if
+// the type comes later in the timeline than the cursor's type.
+func typeCursor(after *timelineCursor, index, cursorIndex int, ascending bool) *store.Cursor {
+	if index == cursorIndex {
+		return &store.Cursor{CreatedAt: after.CreatedAt, ID: after.ID}
+	}
+
+	// Rows created at the cursor time pass a store cursor if their id is
+	// below its id when descending, or above it when ascending.
+	pass, block := math.MaxInt, 0
+	laterType := index > cursorIndex
+	if ascending {
+		pass, block = 0, math.MaxInt
+		laterType = index < cursorIndex
+	}
+
+	if laterType {
+		return &store.Cursor{CreatedAt: after.CreatedAt, ID: pass}
+	}
+	return &store.Cursor{CreatedAt: after.CreatedAt, ID: block}
+}
+
+func encodeTimelineCursor(row map[string]interface{}) string {
+	cursor := store.RowCursor(row)
+	table, _ := row["type"].(string)
+
+	data, _ := json.Marshal(timelineCursor{CreatedAt: cursor.CreatedAt, Table: table, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTimelineCursor(s string) (*timelineCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor timelineCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || store.ParseTime(cursor.CreatedAt).IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := EntryTypeByTable(cursor.Table); !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"fmt"
+	"journal-backend/store"
+	"testing"
+)
+
+const testUser = "11111111-1111-1111-1111-111111111111"
+
+// insertRow adds a row of userID created at createdAt to table and
+// returns its id.
+func insertRow(t *testing.T, entries store.EntryStore, table, userID, createdAt string) int {
+	t.Helper()
+	row, err := entries.Insert(table, map[string]interface{}{"user_id": userID, "created_at": createdAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store.RowCursor(row).ID
+}
+
+// timelineKeys returns type/id of the entries of a timeline page.
+func timelineKeys(page Page) []string {
+	keys := make([]string, len(page.Entries))
+	for i, entry := range page.Entries {
+		keys[i] = fmt.Sprintf("%s/%d", entry.(*TimelineEntry).Type, entry.GetID())
+	}
+	return keys
+}
+
+// timelineFixture stores entries of all types, several of them created at
+// the same time, and returns the complete timeline newest first.
+func timelineFixture(t *testing.T) (*store.Memory, []string) {
+	m := store.NewMemory()
+	const (
+		early = "2024-05-01T08:00:00Z"
+		tie   = "2024-05-02T08:00:00Z"
+		late  = "2024-05-03T08:00:00Z"
+	)
+
+	insertRow(t, m, "journal_entries", testUser, early)     // journal_entries/1
+	insertRow(t, m, "moon_entries", testUser, tie)          // moon_entries/1
+	insertRow(t, m, "journal_entries", testUser, tie)       // journal_entries/2
+	insertRow(t, m, "relationship_check", testUser, tie)    // relationship_check/1
+	insertRow(t, m, "journal_entries", testUser, tie)       // journal_entries/3
+	insertRow(t, m, "moon_entries", testUser, tie)          // moon_entries/2
+	insertRow(t, m, "relationship_check", testUser, late)   // relationship_check/2
+	insertRow(t, m, "journal_entries", "someone else", tie) // journal_entries/4
+	insertRow(t, m, "relationship_check", testUser, tie)    // relationship_check/3
+
+	// Same created_at: by position in EntryTypes, then by id, newest first.
+	return m, []string{
+		"relationship_check/2",
+		"journal_entries/3", "journal_entries/2",
+		"moon_entries/2", "moon_entries/1",
+		"relationship_check/3", "relationship_check/1",
+		"journal_entries/1",
+	}
+}
+
+func TestTimelineOrder(t *testing.T) {
+	m, newestFirst := timelineFixture(t)
+
+	for _, ascending := range []bool{false, true} {
+		page, err := Timeline(m, testUser, Filter{Ascending: ascending}, PageRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := newestFirst
+		if ascending {
+			want = reversed(newestFirst)
+		}
+		if got := timelineKeys(page); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("ascending %v: Timeline() = %v, want %v", ascending, got, want)
+		}
+		if page.NextCursor != nil {
+			t.Errorf("ascending %v: unpaged timeline has a next cursor", ascending)
+		}
+	}
+}
+
+func TestTimelinePaging(t *testing.T) {
+	m, newestFirst := timelineFixture(t)
+
+	for _, ascending := range []bool{false, true} {
+		want := newestFirst
+		if ascending {
+			want = reversed(newestFirst)
+		}
+
+		for limit := 1; limit <= len(want)+1; limit++ {
+			var got []string
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > len(want) {
+					t.Fatalf("ascending %v, limit %d: paging does not end", ascending, limit)
+				}
+				page, err := Timeline(m, testUser, Filter{Ascending: ascending}, PageRequest{Limit: limit, Cursor: cursor})
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, timelineKeys(page)...)
+				if page.NextCursor == nil {
+					break
+				}
+				cursor = *page.NextCursor
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("ascending %v, limit %d: pages = %v, want %v", ascending, limit, got, want)
+			}
+		}
+	}
+}
+
+func TestTimelineRejectsFiltersAndBadCursors(t *testing.T) {
+	m, _ := timelineFixture(t)
+
+	if _, err := Timeline(m, testUser, Filter{Equal: map[string]string{"emotion_color": "Red"}}, PageRequest{}); err == nil {
+		t.Error("Timeline() with a column filter succeeded")
+	}
+
+	invalid := []string{
+		"not base64!",
+		EncodeCursor(store.Cursor{CreatedAt: "2024-05-02T08:00:00Z", ID: 1}),
+		encodeTimelineCursor(map[string]interface{}{"type": "unknown", "created_at": "2024-05-02T08:00:00Z", "id": float64(1)}),
+		encodeTimelineCursor(map[string]interface{}{"type": "moon_entries", "created_at": "yesterday", "id": float64(1)}),
+	}
+	for _, cursor := range invalid {
+		if _, err := Timeline(m, testUser, Filter{}, PageRequest{Limit: 2, Cursor: cursor}); err != ErrInvalidCursor {
+			t.Errorf("Timeline() with cursor %q = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func reversed(s []string) []string {
+	r := make([]string, len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"journal-backend/store"
+	"time"
+)
+
+// DefaultTimezone is used for entries and queries that do not name one.
+const DefaultTimezone = "UTC"
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// LoadTimezone returns the location of an IANA timezone name such as
+// Europe/Berlin. An empty name means DefaultTimezone. The zone of the server
+// ("Local") is not accepted, it differs between deployments.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimezone
+	}
+	if name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// UserLocation returns the timezone of the latest entry of userID. Listings
+// and statistics use it when the request names no timezone, so days are
+// the days of the writer. Without entries, or with a timezone that no
+// longer loads, it returns DefaultTimezone.
+func UserLocation(entries store.EntryStore, userID string) (*time.Location, error) {
+	var latest time.Time
+	name := DefaultTimezone
+
+	for _, entryType := range EntryTypes {
+		rows, err := entries.Select(store.Query{
+			Table:   entryType.Table,
+			Columns: "created_at,timezone",
+			UserID:  userID,
+			Limit:   1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		createdAt := store.ParseTime(store.RowCursor(rows[0]).CreatedAt)
+		timezone, _ := rows[0]["timezone"].(string)
+		if timezone != "" && createdAt.After(latest) {
+			latest, name = createdAt, timezone
+		}
+	}
+
+	loc, err := LoadTimezone(name)
+	if err != nil {
+		return LoadTimezone(DefaultTimezone)
+	}
+	return loc, nil
+}
+
+// FormatTimestamp writes a timestamp read from the store as RFC 3339 in UTC
+// with as many fractional digits as needed, e.g. 2024-04-08T18:21:00Z, so
+// all backends answer alike. Values that are no timestamp are kept.
+func FormatTimestamp(s string) string {
+	t := store.ParseTime(s)
+	if t.IsZero() {
+		return s
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -1,8 +1,8 @@
 package models
 
 import (
-	"journal-backend/db"
 	"journal-backend/logging"
+	"journal-backend/store"
 )
 
 type User struct {
@@ -17,40 +17,37 @@ type UserModify struct {
 	Picture  int    `json:"avatar_url"`
 }
 
-func GetAllUsers(dbClient db.Client) ([]map[string]interface{}, error) {
+// Profile is what GET /profiles tells about a user.
+type Profile struct {
+	Name string `json:"username"`
+}
+
+func GetAllUsers(profiles store.ProfileStore) ([]Profile, error) {
 	logging.Log.Info("Received GET-Request")
 	logging.Log.Info("Selecting UserId + username of all users stored in database...")
 
-	var result []map[string]any
-
 	selectFields := "username"
-	table := "profiles"
-
-	_, err := dbClient.
-		From(table).
-		Select(selectFields, "", false).
-		ExecuteTo(&result)
 
+	rows, err := profiles.SelectProfiles(selectFields)
 	if err != nil {
 		return nil, err
 	}
 
+	result := make([]Profile, len(rows))
+	for i, row := range rows {
+		result[i].Name, _ = row["username"].(string)
+	}
+
 	return result, nil
 }
 
-func NewUser(dbClient db.Client, user interface{}) error {
+func NewUser(profiles store.ProfileStore, user User) error {
 
-	table := "profiles"
-
-	if dbClient.UserID.String() == "" {
-		logging.Log.Error("no user ID available in client")
+	if user.UserId == "" {
+		logging.Log.Error("no user ID available for new profile")
 	}
 
-	_, _, err := dbClient.
-		From(table).
-		Insert(user, false, "", "*", "").
-		Execute()
-
+	err := profiles.InsertProfile(user)
 	if err != nil {
 		return err
 	}
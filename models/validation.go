@@ -0,0 +1,195 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"journal-backend/apierror"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Value types of Schema.
+const (
+	TypeString = "string"
+	TypeArray  = "array"
+)
+
+// EmotionColors is the palette journal entries may use for emotion_color.
+var EmotionColors = []string{"yellow", "orange", "red", "pink", "purple", "blue", "green", "grey"}
+
+// ValidationError lists every rejected field of a payload in the form the
+// API answers with.
+type ValidationError struct {
+	Fields []apierror.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// AsValidationError returns the ValidationError in err, if there is one.
+func AsValidationError(err error) (*ValidationError, bool) {
+	var validationErr *ValidationError
+	ok := errors.As(err, &validationErr)
+	return validationErr, ok
+}
+
+// Rule declares what a field of an entry payload may contain. Fields are
+// strings unless the rule has a Schema.
+type Rule struct {
+	Field    string
+	Required bool
+	// MaxLength is the maximum number of characters of a string.
+	MaxLength int
+	// OneOf lists the allowed values of a string, compared ignoring case.
+	// The value is stored as spelled here.
+	OneOf []string
+	// Schema describes the value of a JSON field.
+	Schema *Schema
+	// Check is called for strings that passed the other checks. Its error
+	// is shown to the client.
+	Check func(string) error
+}
+
+// commonRules apply to the payloads of all entry types.
+var commonRules = []Rule{
+	{Field: "timezone", Check: func(name string) error {
+		if _, err := LoadTimezone(name); err != nil {
+			return errors.New("must be an IANA timezone such as Europe/Berlin")
+		}
+		return nil
+	}},
+}
+
+// Schema is the subset of JSON Schema used for JSON columns.
+type Schema struct {
+	Type      string
+	MaxLength int
+	MaxItems  int
+	Items     *Schema
+}
+
+// ValidatePayload checks raw against the rules of this type and collects
+// all problems in a ValidationError. Allowed values of OneOf fields are
+// written back to raw in their canonical spelling. With partial set, as
+// for updates, required fields may be missing.
+func (t *EntryType) ValidatePayload(raw map[string]interface{}, partial bool) error {
+	var fields []apierror.FieldError
+
+	for _, rule := range slices.Concat(t.Rules, commonRules) {
+		value, present := raw[rule.Field]
+		if !present || value == nil || value == "" {
+			if rule.Required && !partial {
+				fields = append(fields, apierror.FieldError{Field: rule.Field, Message: "is required"})
+			}
+			continue
+		}
+
+		canonical, problems := rule.check(value)
+		fields = append(fields, problems...)
+		if len(problems) == 0 && canonical != nil {
+			raw[rule.Field] = canonical
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// check returns the problems of a present value and, for OneOf rules, the
+// canonical spelling of the value.
+func (r Rule) check(value interface{}) (interface{}, []apierror.FieldError) {
+	if r.Schema != nil {
+		return nil, r.Schema.check(r.Field, value)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, []apierror.FieldError{{Field: r.Field, Message: "must be a string"}}
+	}
+
+	if r.MaxLength > 0 && utf8.RuneCountInString(s) > r.MaxLength {
+		return nil, []apierror.FieldError{{Field: r.Field, Message: fmt.Sprintf("must be at most %d characters", r.MaxLength)}}
+	}
+	if len(r.OneOf) > 0 {
+		if allowed, ok := r.canonical(s); ok {
+			return allowed, nil
+		}
+		return nil, []apierror.FieldError{{Field: r.Field, Message: r.oneOfMessage()}}
+	}
+	if r.Required && strings.TrimSpace(s) == "" {
+		return nil, []apierror.FieldError{{Field: r.Field, Message: "is required"}}
+	}
+	if r.Check != nil {
+		if err := r.Check(s); err != nil {
+			return nil, []apierror.FieldError{{Field: r.Field, Message: err.Error()}}
+		}
+	}
+
+	return nil, nil
+}
+
+// canonical returns the value of OneOf that s spells, ignoring case and
+// surrounding space.
+func (r Rule) canonical(s string) (string, bool) {
+	for _, allowed := range r.OneOf {
+		if strings.EqualFold(allowed, strings.TrimSpace(s)) {
+			return allowed, true
+		}
+	}
+	return "", false
+}
+
+func (r Rule) oneOfMessage() string {
+	return "must be one of " + strings.Join(r.OneOf, ", ")
+}
+
+// rule returns the rule of field, if the type has one.
+func (t *EntryType) rule(field string) (Rule, bool) {
+	for _, rule := range t.Rules {
+		if rule.Field == field {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// check returns the problems of the value at field, naming array items
+// like let_go[2].
+func (s *Schema) check(field string, value interface{}) []apierror.FieldError {
+	switch s.Type {
+	case TypeString:
+		str, ok := value.(string)
+		if !ok {
+			return []apierror.FieldError{{Field: field, Message: "must be a string"}}
+		}
+		if s.MaxLength > 0 && utf8.RuneCountInString(str) > s.MaxLength {
+			return []apierror.FieldError{{Field: field, Message: fmt.Sprintf("must be at most %d characters", s.MaxLength)}}
+		}
+
+	case TypeArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			return []apierror.FieldError{{Field: field, Message: "must be an array"}}
+		}
+		if s.MaxItems > 0 && len(items) > s.MaxItems {
+			return []apierror.FieldError{{Field: field, Message: fmt.Sprintf("must have at most %d items", s.MaxItems)}}
+		}
+		var problems []apierror.FieldError
+		if s.Items != nil {
+			for i, item := range items {
+				problems = append(problems, s.Items.check(fmt.Sprintf("%s[%d]", field, i), item)...)
+			}
+		}
+		return problems
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"journal-backend/store"
+	"strings"
+	"testing"
+)
+
+func TestValidatePayload(t *testing.T) {
+	journal, _ := EntryTypeByTable("journal_entries")
+	moonType, _ := EntryTypeByTable("moon_entries")
+	relationship, _ := EntryTypeByTable("relationship_check")
+
+	items := func(n int, item interface{}) []interface{} {
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = item
+		}
+		return list
+	}
+
+	tests := []struct {
+		name      string
+		entryType *EntryType
+		raw       map[string]interface{}
+		partial   bool
+		// want lists the rejected fields as field: message.
+		want []string
+		// canonical are values raw must hold afterwards.
+		canonical map[string]interface{}
+	}{
+		{
+			name:      "valid journal entry",
+			entryType: journal,
+			raw:       map[string]interface{}{"content": "today", "emotion_color": "blue", "timezone": "Europe/Berlin"},
+		},
+		{
+			name:      "one of ignores case and space",
+			entryType: journal,
+			raw:       map[string]interface{}{"content": "today", "emotion_color": " Red "},
+			canonical: map[string]interface{}{"emotion_color": "red"},
+		},
+		{
+			name:      "missing required field",
+			entryType: journal,
+			raw:       map[string]interface{}{"emotion_color": "red"},
+			want:      []string{"content: is required"},
+		},
+		{
+			name:      "null and empty count as missing",
+			entryType: relationship,
+			raw:       map[string]interface{}{"question": nil, "answer": ""},
+			want:      []string{"question: is required", "answer: is required"},
+		},
+		{
+			name:      "blank required field",
+			entryType: relationship,
+			raw:       map[string]interface{}{"question": "   ", "answer": "yes"},
+			want:      []string{"question: is required"},
+		},
+		{
+			name:      "partial payload may leave out required fields",
+			entryType: journal,
+			raw:       map[string]interface{}{"emotion_color": "GREEN"},
+			partial:   true,
+			canonical: map[string]interface{}{"emotion_color": "green"},
+		},
+		{
+			name:      "all problems are collected",
+			entryType: journal,
+			raw: map[string]interface{}{
+				"content":          strings.Repeat("é", 10001),
+				"content_grateful": 42.0,
+				"emotion_color":    "magenta",
+				"timezone":         "Mars/Olympus",
+			},
+			want: []string{
+				"content: must be at most 10000 characters",
+				"content_grateful: must be a string",
+				"emotion_color: must be one of " + strings.Join(EmotionColors, ", "),
+				"timezone: must be an IANA timezone such as Europe/Berlin",
+			},
+			canonical: map[string]interface{}{"emotion_color": "magenta"},
+		},
+		{
+			name:      "length counts characters",
+			entryType: journal,
+			raw:       map[string]interface{}{"content": strings.Repeat("é", 10000)},
+		},
+		{
+			name:      "server timezone is not accepted",
+			entryType: journal,
+			raw:       map[string]interface{}{"content": "today", "timezone": "Local"},
+			want:      []string{"timezone: must be an IANA timezone such as Europe/Berlin"},
+		},
+		{
+			name:      "valid moon entry",
+			entryType: moonType,
+			raw:       map[string]interface{}{"let_go": items(50, "fear"), "want": []interface{}{}, "moon_sign": "leo"},
+			canonical: map[string]interface{}{"moon_sign": "Leo"},
+		},
+		{
+			name:      "schema of JSON fields",
+			entryType: moonType,
+			raw: map[string]interface{}{
+				"let_go": []interface{}{"fear", 3.0, strings.Repeat("x", 501)},
+				"want":   "everything",
+			},
+			want: []string{
+				"let_go[1]: must be a string",
+				"let_go[2]: must be at most 500 characters",
+				"want: must be an array",
+			},
+		},
+		{
+			name:      "too many items",
+			entryType: moonType,
+			raw:       map[string]interface{}{"let_go": items(51, "fear")},
+			want:      []string{"let_go: must have at most 50 items"},
+		},
+	}
+
+	for _, tt := range tests {
+		err := tt.entryType.ValidatePayload(tt.raw, tt.partial)
+
+		var got []string
+		if err != nil {
+			validationErr, ok := AsValidationError(err)
+			if !ok {
+				t.Fatalf("%s: ValidatePayload() = %v, want a ValidationError", tt.name, err)
+			}
+			for _, f := range validationErr.Fields {
+				got = append(got, f.Field+": "+f.Message)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: ValidatePayload() rejected %q, want %q", tt.name, got, tt.want)
+		}
+
+		for field, want := range tt.canonical {
+			if tt.raw[field] != want {
+				t.Errorf("%s: %s = %v after ValidatePayload(), want %v", tt.name, field, tt.raw[field], want)
+			}
+		}
+	}
+}
+
+func TestFilterOneOf(t *testing.T) {
+	journal, _ := EntryTypeByTable("journal_entries")
+	m := store.NewMemory()
+	for _, color := range []string{"red", "blue", "red"} {
+		if _, err := m.Insert("journal_entries", map[string]interface{}{"user_id": testUser, "emotion_color": color}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "red", want: 2},
+		{value: "Red", want: 2},
+		{value: " BLUE", want: 1},
+		{value: "green", want: 0},
+		{value: "magenta", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		page, err := ListEntries(journal, m, testUser, Filter{Equal: map[string]string{"emotion_color": tt.value}}, PageRequest{})
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("emotion_color=%q: error = %v, want ErrInvalidFilter", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || len(page.Entries) != tt.want {
+			t.Errorf("emotion_color=%q: %d entries, %v, want %d", tt.value, len(page.Entries), err, tt.want)
+		}
+	}
+
+	if _, err := ListEntries(journal, m, testUser, Filter{Equal: map[string]string{"content": "x"}}, PageRequest{}); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("filter on content: error = %v, want ErrInvalidFilter", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"journal-backend/apierror"
+	"journal-backend/moon"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCalendarMonths bounds the range of GET /moon/calendar.
+const maxCalendarMonths = 24
+
+// getMoonCalendar answers GET /moon/calendar?from=...&months=... with the
+// new and full moons in the given number of months from from. from is an
+// RFC 3339 timestamp or a date and defaults to now; months defaults to 3.
+func getMoonCalendar(c *gin.Context) {
+	from, ok := momentParam(c, "from")
+	if !ok {
+		return
+	}
+
+	months := 3
+	if sMonths := c.Query("months"); sMonths != "" {
+		var err error
+		months, err = strconv.Atoi(sMonths)
+		if err != nil || months < 1 || months > maxCalendarMonths {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid months, expected 1 to 24"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": moon.Events(from, from.AddDate(0, months, 0))})
+}
+
+// getMoonPhase answers GET /moon/phase?at=... with the phase and sign of
+// the moon at the given time, by default now.
+func getMoonPhase(c *gin.Context) {
+	at, ok := momentParam(c, "at")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, moon.At(at))
+}
+
+// momentParam parses an optional query parameter holding an RFC 3339
+// timestamp or a date, which means midnight UTC. It defaults to now. Only
+// times the moon package supports are accepted.
+func momentParam(c *gin.Context, name string) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Now().UTC().Truncate(time.Second), true
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid "+name+", expected YYYY-MM-DD or RFC 3339 timestamp"))
+		return time.Time{}, false
+	}
+	if !moon.Supported(t) {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid "+name+", expected a time from 1900 to 2199"))
+		return time.Time{}, false
+	}
+
+	return t, true
+}
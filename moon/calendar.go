@@ -0,0 +1,122 @@
+package moon
+
+import (
+	"math"
+	"time"
+)
+
+// synodicMonth is the mean time from one new moon to the next, in days.
+const synodicMonth = 29.530588861
+
+// deltaT approximates the difference between dynamical and universal time
+// in the 2020s, which the phase algorithm needs.
+const deltaT = 69 * time.Second
+
+// Event is a new or full moon.
+type Event struct {
+	// Phase is NewMoon or FullMoon.
+	Phase string    `json:"phase"`
+	Time  time.Time `json:"time"`
+	// Sign is the zodiac sign the moon is in.
+	Sign string `json:"sign"`
+}
+
+// Events returns the new and full moons from from until until, in order.
+// Times outside MinTime and MaxTime are not supported.
+func Events(from, until time.Time) []Event {
+	events := []Event{}
+
+	// The lunation numbers k count new moons from January 2000; one more on
+	// either side makes sure no event in the range is missed.
+	k := math.Floor(lunation(from)) - 1
+	last := math.Ceil(lunation(until)) + 1
+
+	for ; k <= last; k++ {
+		for _, phase := range []struct {
+			name   string
+			offset float64
+		}{{NewMoon, 0}, {FullMoon, 0.5}} {
+			t := phaseTime(k+phase.offset, phase.name == FullMoon)
+			if t.Before(from) {
+				continue
+			}
+			if !t.Before(until) {
+				return events
+			}
+			events = append(events, Event{Phase: phase.name, Time: t, Sign: SignAt(t)})
+		}
+	}
+	return events
+}
+
+// lunation returns the approximate number of new moons between January
+// 2000 and t.
+func lunation(t time.Time) float64 {
+	years := float64(t.Unix())/(365.2425*86400) + 1970 - 2000
+	return years * 12.3685
+}
+
+// phaseTerm is one periodic correction of a new or full moon time: the
+// multiples of M, M', F and Ω, the power of E the amplitude is scaled with
+// and the amplitude in days.
+type phaseTerm struct {
+	m, mp, f, omega float64
+	e               int
+	newMoon         float64
+	fullMoon        float64
+}
+
+// phaseTerms are the corrections of chapter 49 for new and full moons.
+var phaseTerms = []phaseTerm{
+	{0, 1, 0, 0, 0, -0.40720, -0.40614},
+	{1, 0, 0, 0, 1, 0.17241, 0.17302},
+	{0, 2, 0, 0, 0, 0.01608, 0.01614},
+	{0, 0, 2, 0, 0, 0.01039, 0.01043},
+	{-1, 1, 0, 0, 1, 0.00739, 0.00734},
+	{1, 1, 0, 0, 1, -0.00514, -0.00515},
+	{2, 0, 0, 0, 2, 0.00208, 0.00209},
+	{0, 1, -2, 0, 0, -0.00111, -0.00111},
+	{0, 1, 2, 0, 0, -0.00057, -0.00057},
+	{1, 2, 0, 0, 1, 0.00056, 0.00056},
+	{0, 3, 0, 0, 0, -0.00042, -0.00042},
+	{1, 0, 2, 0, 1, 0.00042, 0.00042},
+	{1, 0, -2, 0, 1, 0.00038, 0.00038},
+	{-1, 2, 0, 0, 1, -0.00024, -0.00024},
+	{0, 0, 0, 1, 0, -0.00017, -0.00017},
+	{2, 1, 0, 0, 0, -0.00007, -0.00007},
+	{0, 2, -2, 0, 0, 0.00004, 0.00004},
+	{3, 0, 0, 0, 0, 0.00004, 0.00004},
+	{1, 1, -2, 0, 0, 0.00003, 0.00003},
+	{0, 2, 2, 0, 0, 0.00003, 0.00003},
+	{1, 1, 2, 0, 0, -0.00003, -0.00003},
+	{-1, 1, 2, 0, 0, 0.00003, 0.00003},
+	{-1, 1, -2, 0, 0, -0.00002, -0.00002},
+	{1, 3, 0, 0, 0, -0.00002, -0.00002},
+	{0, 4, 0, 0, 0, 0.00002, 0.00002},
+}
+
+// phaseTime returns the time of the new moon with number k, counted from
+// January 2000, or of the full moon k+0.5 (Meeus ch. 49).
+func phaseTime(k float64, full bool) time.Time {
+	t := k / 1236.85
+
+	jde := 2451550.09766 + synodicMonth*k +
+		0.00015437*t*t - 0.000000150*t*t*t + 0.00000000073*t*t*t*t
+	e := 1 - 0.002516*t - 0.0000074*t*t
+	m := 2.5534 + 29.10535670*k - 0.0000014*t*t - 0.00000011*t*t*t
+	mp := 201.5643 + 385.81693528*k + 0.0107582*t*t + 0.00001238*t*t*t - 0.000000058*t*t*t*t
+	f := 160.7108 + 390.67050284*k - 0.0016118*t*t - 0.00000227*t*t*t + 0.000000011*t*t*t*t
+	omega := 124.7746 - 1.56375588*k + 0.0020672*t*t + 0.00000215*t*t*t
+
+	for _, term := range phaseTerms {
+		amplitude := term.newMoon
+		if full {
+			amplitude = term.fullMoon
+		}
+		amplitude *= math.Pow(e, float64(term.e))
+		jde += amplitude * math.Sin(rad(term.m*m+term.mp*mp+term.f*f+term.omega*omega))
+	}
+
+	sec, frac := math.Modf((jde - 2440587.5) * 86400)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC().Add(-deltaT).Truncate(time.Second)
+}
@@ -0,0 +1,112 @@
+package moon
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Reference times of new and full moons, from the tables of the US Naval
+// Observatory, and the signs of the zodiac they fall in.
+var referenceEvents = []Event{
+	{Phase: NewMoon, Time: time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC), Sign: "Capricorn"},
+	{Phase: FullMoon, Time: time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC), Sign: "Leo"},
+	{Phase: NewMoon, Time: time.Date(2024, 2, 9, 22, 59, 0, 0, time.UTC), Sign: "Aquarius"},
+	{Phase: FullMoon, Time: time.Date(2024, 2, 24, 12, 30, 0, 0, time.UTC), Sign: "Virgo"},
+}
+
+func TestEvents(t *testing.T) {
+	events := Events(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if len(events) != len(referenceEvents) {
+		t.Fatalf("Events() = %+v, want %d events", events, len(referenceEvents))
+	}
+
+	for i, want := range referenceEvents {
+		got := events[i]
+		if got.Phase != want.Phase || got.Sign != want.Sign {
+			t.Errorf("event %d = %s in %s, want %s in %s", i, got.Phase, got.Sign, want.Phase, want.Sign)
+		}
+		if diff := got.Time.Sub(want.Time).Abs(); diff > 3*time.Minute {
+			t.Errorf("%s at %s, want %s", got.Phase, got.Time, want.Time)
+		}
+	}
+}
+
+func TestEventsRange(t *testing.T) {
+	newMoon := referenceEvents[0].Time
+
+	// The range includes from and excludes until.
+	if events := Events(newMoon.Add(-time.Hour), newMoon.Add(time.Hour)); len(events) != 1 || events[0].Phase != NewMoon {
+		t.Errorf("Events() around the new moon = %+v, want only it", events)
+	}
+	if events := Events(newMoon.Add(time.Hour), newMoon.Add(2*time.Hour)); len(events) != 0 {
+		t.Errorf("Events() between phases = %+v, want none", events)
+	}
+
+	events := Events(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(events) != 25 {
+		t.Errorf("Events() in 2024 = %d events, want 25", len(events))
+	}
+	for i := 1; i < len(events); i++ {
+		if !events[i-1].Time.Before(events[i].Time) || events[i-1].Phase == events[i].Phase {
+			t.Errorf("events %d and %d are out of order: %+v, %+v", i-1, i, events[i-1], events[i])
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		time         time.Time
+		phase        string
+		angle        float64
+		illumination float64
+		sign         string
+	}{
+		{time: referenceEvents[0].Time, phase: NewMoon, angle: 0, illumination: 0, sign: "Capricorn"},
+		{time: time.Date(2024, 1, 18, 3, 53, 0, 0, time.UTC), phase: FirstQuarter, angle: 90, illumination: 0.5, sign: "Aries"},
+		{time: referenceEvents[1].Time, phase: FullMoon, angle: 180, illumination: 1, sign: "Leo"},
+		{time: time.Date(2024, 2, 2, 23, 18, 0, 0, time.UTC), phase: LastQuarter, angle: 270, illumination: 0.5, sign: "Scorpio"},
+	}
+
+	for _, tt := range tests {
+		got := At(tt.time)
+		if got.Name != tt.phase || got.Sign != tt.sign {
+			t.Errorf("At(%s) = %s in %s, want %s in %s", tt.time, got.Name, got.Sign, tt.phase, tt.sign)
+		}
+		// The phase changes by about 0.5° an hour.
+		if diff := math.Abs(math.Remainder(got.Angle-tt.angle, 360)); diff > 0.5 {
+			t.Errorf("At(%s).Angle = %.2f, want %.0f", tt.time, got.Angle, tt.angle)
+		}
+		if math.Abs(got.Illumination-tt.illumination) > 0.01 {
+			t.Errorf("At(%s).Illumination = %.3f, want %.1f", tt.time, got.Illumination, tt.illumination)
+		}
+		if got.Sign != SignAt(tt.time) {
+			t.Errorf("SignAt(%s) = %s, want %s", tt.time, SignAt(tt.time), got.Sign)
+		}
+	}
+}
+
+func TestParseSign(t *testing.T) {
+	if sign, ok := ParseSign(" leo "); !ok || sign != "Leo" {
+		t.Errorf("ParseSign(leo) = %q, %v", sign, ok)
+	}
+	if _, ok := ParseSign("Ophiuchus"); ok {
+		t.Error("ParseSign(Ophiuchus) succeeded")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, tt := range []struct {
+		time time.Time
+		want bool
+	}{
+		{MinTime, true},
+		{MinTime.Add(-time.Second), false},
+		{MaxTime.Add(-time.Second), true},
+		{MaxTime, false},
+	} {
+		if got := Supported(tt.time); got != tt.want {
+			t.Errorf("Supported(%s) = %v, want %v", tt.time, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package moon computes lunar phases, the zodiac sign of the moon and the
+// times of new and full moons offline, with the low precision algorithms of
+// Jean Meeus, Astronomical Algorithms (2nd ed.). Positions are good to a few
+// hundredths of a degree and phase times to a few minutes.
+package moon
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Names of the eight phases.
+const (
+	NewMoon        = "new_moon"
+	WaxingCrescent = "waxing_crescent"
+	FirstQuarter   = "first_quarter"
+	WaxingGibbous  = "waxing_gibbous"
+	FullMoon       = "full_moon"
+	WaningGibbous  = "waning_gibbous"
+	LastQuarter    = "last_quarter"
+	WaningCrescent = "waning_crescent"
+)
+
+var phaseNames = []string{
+	NewMoon, WaxingCrescent, FirstQuarter, WaxingGibbous,
+	FullMoon, WaningGibbous, LastQuarter, WaningCrescent,
+}
+
+// MinTime and MaxTime bound the times the algorithms are used for. Outside
+// of them the error of the approximations grows quickly.
+var (
+	MinTime = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	MaxTime = time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Supported reports whether t is between MinTime and MaxTime.
+func Supported(t time.Time) bool {
+	return !t.Before(MinTime) && t.Before(MaxTime)
+}
+
+// Signs are the signs of the tropical zodiac, starting at 0° longitude.
+var Signs = []string{
+	"Aries", "Taurus", "Gemini", "Cancer", "Leo", "Virgo",
+	"Libra", "Scorpio", "Sagittarius", "Capricorn", "Aquarius", "Pisces",
+}
+
+// Phase describes the moon at one moment.
+type Phase struct {
+	Time time.Time `json:"time"`
+	// Name is the closest of the eight phases.
+	Name string `json:"phase"`
+	// Angle is the elongation of the moon from the sun in degrees: 0 at new
+	// moon, 180 at full moon.
+	Angle float64 `json:"angle"`
+	// Illumination is the illuminated fraction of the disk, from 0 to 1.
+	Illumination float64 `json:"illumination"`
+	// Longitude is the ecliptic longitude of the moon in degrees.
+	Longitude float64 `json:"longitude"`
+	Sign      string  `json:"sign"`
+}
+
+// At returns the phase of the moon at t.
+func At(t time.Time) Phase {
+	jc := centuries(t)
+	moonLon := moonLongitude(jc)
+	angle := normalize(moonLon - sunLongitude(jc))
+
+	return Phase{
+		Time:         t.UTC(),
+		Name:         phaseNames[int(math.Floor(normalize(angle+22.5)/45))%8],
+		Angle:        angle,
+		Illumination: (1 - math.Cos(rad(angle))) / 2,
+		Longitude:    moonLon,
+		Sign:         signOf(moonLon),
+	}
+}
+
+// SignAt returns the zodiac sign the moon is in at t.
+func SignAt(t time.Time) string {
+	return signOf(moonLongitude(centuries(t)))
+}
+
+// ParseSign returns the canonical name of a zodiac sign, ignoring case.
+func ParseSign(s string) (string, bool) {
+	for _, sign := range Signs {
+		if strings.EqualFold(sign, strings.TrimSpace(s)) {
+			return sign, true
+		}
+	}
+	return "", false
+}
+
+func signOf(longitude float64) string {
+	return Signs[int(normalize(longitude)/30)%12]
+}
+
+// centuries returns the Julian centuries since J2000.0 at t. The difference
+// between universal and dynamical time is below the precision used here.
+func centuries(t time.Time) float64 {
+	jd := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+	return (jd - 2451545.0) / 36525
+}
+
+// sunLongitude returns the apparent longitude of the sun (Meeus ch. 25).
+func sunLongitude(t float64) float64 {
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := rad(357.52911 + 35999.05029*t - 0.0001537*t*t)
+
+	c := (1.914602-0.004817*t-0.000014*t*t)*math.Sin(m) +
+		(0.019993-0.000101*t)*math.Sin(2*m) +
+		0.000289*math.Sin(3*m)
+
+	omega := rad(125.04 - 1934.136*t)
+	return normalize(l0 + c - 0.00569 - 0.00478*math.Sin(omega))
+}
+
+// lunarTerm is one periodic term of the moon's longitude: the multiples of
+// D, M, M' and F and the amplitude in degrees.
+type lunarTerm struct {
+	d, m, mp, f float64
+	amplitude   float64
+}
+
+// lunarTerms are the largest terms of table 47.A.
+var lunarTerms = []lunarTerm{
+	{0, 0, 1, 0, 6.288774},
+	{2, 0, -1, 0, 1.274027},
+	{2, 0, 0, 0, 0.658314},
+	{0, 0, 2, 0, 0.213618},
+	{0, 1, 0, 0, -0.185116},
+	{0, 0, 0, 2, -0.114332},
+	{2, 0, -2, 0, 0.058793},
+	{2, -1, -1, 0, 0.057066},
+	{2, 0, 1, 0, 0.053322},
+	{2, -1, 0, 0, 0.045758},
+	{0, 1, -1, 0, -0.040923},
+	{1, 0, 0, 0, -0.034720},
+	{0, 1, 1, 0, -0.030383},
+	{2, 0, 0, -2, 0.015327},
+	{0, 0, 1, 2, -0.012528},
+	{0, 0, 1, -2, 0.010980},
+	{4, 0, -1, 0, 0.010675},
+	{0, 0, 3, 0, 0.010034},
+	{4, 0, -2, 0, 0.008548},
+	{2, 1, -1, 0, -0.007888},
+	{2, 1, 0, 0, -0.006766},
+	{1, 0, -1, 0, -0.005163},
+	{1, 1, 0, 0, 0.004987},
+	{2, -1, 1, 0, 0.004036},
+	{2, 0, 2, 0, 0.003994},
+	{4, 0, 0, 0, 0.003861},
+	{2, 0, -3, 0, 0.003665},
+	{0, 1, -2, 0, -0.002689},
+	{2, 0, -1, 2, -0.002602},
+	{2, -1, -2, 0, 0.002390},
+	{1, 0, 1, 0, -0.002348},
+	{2, -2, 0, 0, 0.002236},
+}
+
+// moonLongitude returns the geocentric longitude of the moon (Meeus ch. 47).
+func moonLongitude(t float64) float64 {
+	lp := 218.3164477 + 481267.88123421*t - 0.0015786*t*t
+	d := 297.8501921 + 445267.1114034*t - 0.0018819*t*t
+	m := 357.5291092 + 35999.0502909*t - 0.0001536*t*t
+	mp := 134.9633964 + 477198.8675055*t + 0.0087414*t*t
+	f := 93.2720950 + 483202.0175233*t - 0.0036539*t*t
+	e := 1 - 0.002516*t - 0.0000074*t*t
+
+	sum := 0.0
+	for _, term := range lunarTerms {
+		amplitude := term.amplitude
+		// Terms depending on the sun's anomaly shrink with the
+		// eccentricity of the earth's orbit.
+		switch math.Abs(term.m) {
+		case 1:
+			amplitude *= e
+		case 2:
+			amplitude *= e * e
+		}
+		sum += amplitude * math.Sin(rad(term.d*d+term.m*m+term.mp*mp+term.f*f))
+	}
+
+	return normalize(lp + sum)
+}
+
+func rad(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// normalize reduces an angle in degrees to [0, 360).
+func normalize(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journal-backend/apierror"
+	"journal-backend/helpers"
+	"journal-backend/logging"
+	"journal-backend/models"
+	"journal-backend/store"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerEntryResources adds the REST routes of every entry type, e.g.
+// GET/POST /journal-entries and GET/PATCH/DELETE /journal-entries/:id.
+func registerEntryResources(group *gin.RouterGroup) {
+	for _, entryType := range models.EntryTypes {
+		resource := group.Group("/" + entryType.Path)
+		resource.GET("", listResource(entryType))
+		resource.POST("", createResource(entryType))
+		resource.GET("/:id", getResource(entryType))
+		resource.PATCH("/:id", patchResource(entryType))
+		resource.DELETE("/:id", deleteResource(entryType))
+	}
+}
+
+// getTimeline answers GET /timeline with the entries of all types merged by
+// created_at. It takes the same from, to, order, limit and cursor parameters
+// as the listings of the single types.
+func getTimeline(c *gin.Context) {
+	logging.Log.Debug("Received GET-Request for the timeline")
+
+	filter, ok := listFilter(c)
+	if !ok {
+		return
+	}
+	page, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+
+	timeline, err := models.Timeline(userStore(c), currentUserID(c), filter, page)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	respondPage(c, page, timeline)
+}
+
+// searchEntries answers GET /search?q=...&type=...&limit=... with the
+// caller's entries matching q, best first. type is an optional comma
+// separated list of tables.
+func searchEntries(c *gin.Context) {
+	logging.Log.Debug("Received GET-Request to search entries")
+
+	entryTypes := models.EntryTypes
+	if types := c.Query("type"); types != "" {
+		entryTypes = nil
+		for _, table := range strings.Split(types, ",") {
+			entryType, ok := models.EntryTypeByTable(strings.TrimSpace(table))
+			if !ok {
+				apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Unknown type "+table))
+				return
+			}
+			entryTypes = append(entryTypes, entryType)
+		}
+	}
+
+	limit := 0
+	if sLimit := c.Query("limit"); sLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(sLimit)
+		if err != nil || limit < 1 {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid limit"))
+			return
+		}
+	}
+
+	results, err := models.Search(userStore(c), currentUserID(c), c.Query("q"), entryTypes, limit)
+	if errors.Is(err, models.ErrEmptySearch) {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Missing search query"))
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to search entries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// deprecated marks the responses of routes that have a REST replacement.
+// The handlers name the replacement with successorLink once they know the
+// entry type of the request.
+func deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Next()
+	}
+}
+
+// successorLink links the response of a legacy route to the resource of
+// entryType that replaces it, or to the entry with id if id is not zero.
+func successorLink(c *gin.Context, entryType *models.EntryType, id int) {
+	successor := "/" + entryType.Path
+	if id != 0 {
+		successor += "/" + strconv.Itoa(id)
+	}
+	c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+}
+
+func listResource(entryType *models.EntryType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logging.Log.Debug("Received GET-Request for ", entryType.Path)
+
+		filter, ok := listFilter(c)
+		if !ok {
+			return
+		}
+		page, ok := pageRequest(c)
+		if !ok {
+			return
+		}
+
+		entries, err := models.ListEntries(entryType, userStore(c), currentUserID(c), filter, page)
+		if err != nil {
+			respondListError(c, err)
+			return
+		}
+
+		respondPage(c, page, entries)
+	}
+}
+
+func getResource(entryType *models.EntryType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logging.Log.Debug("Received GET-Request for one of ", entryType.Path)
+
+		id, ok := entryID(c)
+		if !ok {
+			return
+		}
+
+		entry, err := models.GetEntry(entryType, userStore(c), id, currentUserID(c))
+		if errors.Is(err, store.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.NotFound, "Entry not found"))
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to fetch entry"))
+			return
+		}
+
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+func createResource(entryType *models.EntryType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logging.Log.Debug("Received POST-Request for ", entryType.Path)
+
+		var raw map[string]interface{}
+		if err := c.ShouldBindJSON(&raw); err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
+			return
+		}
+
+		inserted, ok := insertEntry(c, entryType, raw)
+		if !ok {
+			return
+		}
+
+		c.Header("Location", fmt.Sprintf("%s/%d", c.Request.URL.Path, inserted.GetID()))
+		c.JSON(http.StatusCreated, inserted)
+	}
+}
+
+func patchResource(entryType *models.EntryType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logging.Log.Debug("Received PATCH-Request for ", entryType.Path)
+
+		id, ok := entryID(c)
+		if !ok {
+			return
+		}
+
+		var raw map[string]interface{}
+		if err := c.ShouldBindJSON(&raw); err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid request body"))
+			return
+		}
+
+		if !modifyEntry(c, entryType, id, raw) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func deleteResource(entryType *models.EntryType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logging.Log.Debug("Received DELETE-Request for ", entryType.Path)
+
+		id, ok := entryID(c)
+		if !ok {
+			return
+		}
+
+		if !removeEntry(c, entryType, id) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// listQueryKeys are the query parameters of listings that are not
+// column filters.
+var listQueryKeys = []string{"selected_index", "limit", "cursor", "from", "to", "tz", "order"}
+
+// listFilter parses from, to, tz, order and the column filters of a listing.
+// Every other query parameter is taken as a column filter; the entry type
+// decides which columns are allowed.
+func listFilter(c *gin.Context) (models.Filter, bool) {
+	filter := models.Filter{
+		From:  c.Query("from"),
+		To:    c.Query("to"),
+		Equal: make(map[string]string),
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return filter, false
+	}
+	filter.Location = loc
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid order, expected asc or desc"))
+		return filter, false
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		if !slices.Contains(listQueryKeys, key) {
+			filter.Equal[key] = values[0]
+		}
+	}
+
+	return filter, true
+}
+
+// requestLocation returns the timezone named by the tz query parameter, or
+// the one of the caller's latest entry. On failure it answers the request
+// itself and returns false.
+func requestLocation(c *gin.Context) (*time.Location, bool) {
+	if name := c.Query("tz"); name != "" {
+		loc, err := models.LoadTimezone(name)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid timezone"))
+			return nil, false
+		}
+		return loc, true
+	}
+
+	loc, err := models.UserLocation(userStore(c), currentUserID(c))
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to look up the timezone"))
+		return nil, false
+	}
+	return loc, true
+}
+
+// respondListError answers a listing that failed.
+func respondListError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidCursor):
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid cursor"))
+	case errors.Is(err, models.ErrInvalidFilter):
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, err.Error()))
+	default:
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to fetch entries"))
+	}
+}
+
+// pageRequest parses the optional limit and cursor query parameters.
+func pageRequest(c *gin.Context) (models.PageRequest, bool) {
+	var page models.PageRequest
+
+	if sLimit := c.Query("limit"); sLimit != "" {
+		limit, err := strconv.Atoi(sLimit)
+		if err != nil || limit < 1 {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid limit"))
+			return page, false
+		}
+		page.Limit = limit
+	}
+	page.Cursor = c.Query("cursor")
+
+	return page, true
+}
+
+// respondPage answers with the entries of page. Clients that did not ask
+// for pagination get the plain list they always got.
+func respondPage(c *gin.Context, request models.PageRequest, page models.Page) {
+	if request == (models.PageRequest{}) {
+		c.JSON(http.StatusOK, page.Entries)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// entryID parses the :id path parameter.
+func entryID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid entry id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// insertEntry stores raw as a new entry of the caller and returns it. On
+// failure it answers the request itself and returns false.
+func insertEntry(c *gin.Context, entryType *models.EntryType, raw map[string]interface{}) (models.Entry, bool) {
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+
+	if err := entryType.ValidatePayload(raw, false); err != nil {
+		respondInvalidEntry(c, entryType, err)
+		return nil, false
+	}
+
+	timezone, _ := raw["timezone"].(string)
+	if timezone == "" {
+		timezone = models.DefaultTimezone
+	}
+
+	entry, err := entryType.Prepare(raw, currentUserID(c), createdAt, timezone)
+	if err != nil {
+		respondInvalidEntry(c, entryType, err)
+		return nil, false
+	}
+	if err := entryType.Check(entry); err != nil {
+		respondInvalidEntry(c, entryType, err)
+		return nil, false
+	}
+
+	row := helpers.ToMap(entry)
+
+	logging.Log.Infof("Inserting entry into table '%s': %+v", entryType.Table, row)
+
+	inserted, err := models.InsertEntry(userStore(c), row, entryType.Table)
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to insert entry"))
+		return nil, false
+	}
+
+	streaks.Record(currentUserID(c), entryType.Table, inserted.GetID(), inserted.GetCreatedAt())
+
+	return inserted, true
+}
+
+// modifyEntry applies the non-empty fields of raw to the caller's entry with
+// id. On failure it answers the request itself and returns false.
+func modifyEntry(c *gin.Context, entryType *models.EntryType, id int, raw map[string]interface{}) bool {
+	userID := currentUserID(c)
+
+	if err := entryType.ValidatePayload(raw, true); err != nil {
+		respondInvalidEntry(c, entryType, err)
+		return false
+	}
+
+	raw["id"] = id
+	entry, err := entryType.Prepare(raw, userID, "", "")
+	if err != nil {
+		respondInvalidEntry(c, entryType, err)
+		return false
+	}
+
+	row := helpers.ToMap(entry)
+
+	logging.Log.Infof("Updating entry in table '%s': %+v", entryType.Table, row)
+
+	err = models.UpdateEntry(userStore(c), row, entryType.Table, id, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		apierror.Respond(c, apierror.New(apierror.NotFound, "Entry not found"))
+		return false
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to update entry"))
+		return false
+	}
+	streaks.Forget(userID)
+
+	return true
+}
+
+// respondInvalidEntry answers a payload that was rejected. Validation errors
+// list the rejected fields; anything else did not match the entry struct,
+// which names the field if it had the wrong JSON type.
+func respondInvalidEntry(c *gin.Context, entryType *models.EntryType, err error) {
+	var fields []apierror.FieldError
+
+	var typeErr *json.UnmarshalTypeError
+	if validationErr, ok := models.AsValidationError(err); ok {
+		fields = validationErr.Fields
+	} else if errors.As(err, &typeErr) && typeErr.Field != "" {
+		fields = append(fields, apierror.FieldError{Field: typeErr.Field, Message: "has the wrong type"})
+	}
+
+	apierror.Respond(c, apierror.Validation("Invalid "+entryType.Name, fields))
+}
+
+// removeEntry deletes the caller's entry with id. On failure it answers the
+// request itself and returns false.
+func removeEntry(c *gin.Context, entryType *models.EntryType, id int) bool {
+	logging.Log.Debug("Delete from ", entryType.Table, " where id= ", id)
+
+	err := models.DeleteEntry(userStore(c), entryType.Table, id, currentUserID(c))
+	if errors.Is(err, store.ErrNotFound) {
+		apierror.Respond(c, apierror.New(apierror.NotFound, "Entry not found"))
+		return false
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to delete entry"))
+		return false
+	}
+	streaks.Forget(currentUserID(c))
+
+	return true
+}
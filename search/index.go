@@ -0,0 +1,238 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// snippetRadius is the number of words shown around the first match.
+const snippetRadius = 8
+
+// Highlight markers put around matching words in snippets.
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// Document is a piece of content with named text fields.
+type Document struct {
+	Key    string
+	Fields map[string]string
+}
+
+// Hit is a document matching a query.
+type Hit struct {
+	Key     string
+	Score   float64
+	Snippet string
+}
+
+type posting struct {
+	doc   int
+	count int
+}
+
+// Index is an in-memory inverted index ranking documents with BM25.
+type Index struct {
+	docs     []Document
+	lengths  []int
+	total    int
+	postings map[string][]posting
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{postings: make(map[string][]posting)}
+}
+
+// Add indexes all fields of d.
+func (ix *Index) Add(d Document) {
+	id := len(ix.docs)
+	counts := make(map[string]int)
+	length := 0
+
+	for _, text := range d.Fields {
+		for _, t := range tokenize(text) {
+			counts[t.term]++
+			length++
+		}
+	}
+
+	for term, count := range counts {
+		ix.postings[term] = append(ix.postings[term], posting{doc: id, count: count})
+	}
+	ix.docs = append(ix.docs, d)
+	ix.lengths = append(ix.lengths, length)
+	ix.total += length
+}
+
+// Search returns the best matches for query, at most limit of them.
+// A limit of 0 returns all matches.
+func (ix *Index) Search(query string, limit int) []Hit {
+	terms := Terms(query)
+	if len(terms) == 0 || len(ix.docs) == 0 {
+		return nil
+	}
+
+	avgLength := float64(ix.total) / float64(len(ix.docs))
+	scores := make(map[int]float64)
+
+	for _, term := range terms {
+		postings := ix.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		n := float64(len(postings))
+		idf := math.Log(1 + (float64(len(ix.docs))-n+0.5)/(n+0.5))
+		for _, p := range postings {
+			tf := float64(p.count)
+			norm := 1 - b + b*float64(ix.lengths[p.doc])/avgLength
+			scores[p.doc] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, Hit{
+			Key:     ix.docs[doc].Key,
+			Score:   score,
+			Snippet: Snippet(ix.docs[doc].Fields, terms),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key < hits[j].Key
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Terms returns the distinct normalized terms of a query.
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+
+	for _, t := range tokenize(query) {
+		if !seen[t.term] {
+			seen[t.term] = true
+			terms = append(terms, t.term)
+		}
+	}
+	return terms
+}
+
+// Snippet returns an excerpt of the field matching most of terms, with the
+// matching words wrapped in MarkStart and MarkEnd. The text is HTML-escaped,
+// so the markers are the only markup in the snippet.
+func Snippet(fields map[string]string, terms []string) string {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	// Sorted names keep the choice stable between equally good fields.
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var best []token
+	var bestText string
+	bestMatches := 0
+	for _, name := range names {
+		tokens := tokenize(fields[name])
+		matches := 0
+		for _, t := range tokens {
+			if wanted[t.term] {
+				matches++
+			}
+		}
+		if matches > bestMatches {
+			best, bestText, bestMatches = tokens, fields[name], matches
+		}
+	}
+	if bestMatches == 0 {
+		return ""
+	}
+
+	first := 0
+	for i, t := range best {
+		if wanted[t.term] {
+			first = i
+			break
+		}
+	}
+	from := max(first-snippetRadius, 0)
+	to := min(first+snippetRadius, len(best)-1)
+
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("… ")
+	}
+	pos := best[from].start
+	for _, t := range best[from : to+1] {
+		sb.WriteString(html.EscapeString(bestText[pos:t.start]))
+		if wanted[t.term] {
+			sb.WriteString(MarkStart + html.EscapeString(bestText[t.start:t.end]) + MarkEnd)
+		} else {
+			sb.WriteString(html.EscapeString(bestText[t.start:t.end]))
+		}
+		pos = t.end
+	}
+	if to < len(best)-1 {
+		sb.WriteString(" …")
+	}
+
+	return sb.String()
+}
+
+// Highlight HTML-escapes text in which matches start with start and end
+// with end, and replaces those with MarkStart and MarkEnd. It turns
+// highlights made by another engine into the form of Snippet.
+func Highlight(text, start, end string) string {
+	return strings.NewReplacer(start, MarkStart, end, MarkEnd).Replace(html.EscapeString(text))
+}
+
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lower-cased words made of letters and digits.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+
+	return tokens
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	got := Terms("Walk, walk; the DOG 2 times! Über")
+	want := []string{"walk", "the", "dog", "2", "times", "über"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+	if got := Terms(" ,.!? "); got != nil {
+		t.Errorf("Terms() of punctuation = %q, want none", got)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(Document{Key: "a", Fields: map[string]string{"content": "A walk in the park with the dog."}})
+	ix.Add(Document{Key: "b", Fields: map[string]string{"content": "Dog, dog and more dog."}})
+	ix.Add(Document{Key: "c", Fields: map[string]string{"content": "Nothing to see here."}})
+	ix.Add(Document{Key: "d", Fields: map[string]string{"content": "Quiet day.", "proud": "Trained the dog to sit."}})
+
+	keys := func(hits []Hit) []string {
+		var keys []string
+		for _, hit := range hits {
+			keys = append(keys, hit.Key)
+		}
+		return keys
+	}
+
+	hits := ix.Search("dog", 0)
+	if got := keys(hits); len(got) != 3 || got[0] != "b" {
+		t.Errorf("Search(dog) = %q, want b first of a, b and d", got)
+	}
+	if got := keys(ix.Search("DOG park", 0)); got[0] != "a" {
+		t.Errorf("Search(DOG park) = %q, want a first", got)
+	}
+	if got := keys(ix.Search("dog", 2)); len(got) != 2 {
+		t.Errorf("Search(dog, 2) = %q, want 2 hits", got)
+	}
+	if got := ix.Search("cat", 0); len(got) != 0 {
+		t.Errorf("Search(cat) = %q, want none", keys(got))
+	}
+	if got := ix.Search("!!", 0); got != nil {
+		t.Errorf("Search(!!) = %q, want none", keys(got))
+	}
+	if got := NewIndex().Search("dog", 0); got != nil {
+		t.Errorf("Search() of an empty index = %q, want none", keys(got))
+	}
+
+	for _, hit := range hits {
+		if hit.Score <= 0 {
+			t.Errorf("hit %s has score %v", hit.Key, hit.Score)
+		}
+		if !strings.Contains(strings.ToLower(hit.Snippet), MarkStart+"dog"+MarkEnd) {
+			t.Errorf("snippet of %s = %q, want a highlighted dog", hit.Key, hit.Snippet)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		terms  []string
+		want   string
+	}{
+		{
+			name:   "highlights matches",
+			fields: map[string]string{"content": "I walked the Dog today."},
+			terms:  []string{"dog"},
+			want:   "I walked the <mark>Dog</mark> today",
+		},
+		{
+			name:   "picks the field with most matches",
+			fields: map[string]string{"a": "dog", "b": "dog and cat"},
+			terms:  []string{"dog", "cat"},
+			want:   "<mark>dog</mark> and <mark>cat</mark>",
+		},
+		{
+			name:   "cuts long text around the first match",
+			fields: map[string]string{"content": "one two three four five six seven eight nine ten dog eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen"},
+			terms:  []string{"dog"},
+			want:   "… three four five six seven eight nine ten <mark>dog</mark> eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen …",
+		},
+		{
+			name:   "escapes HTML",
+			fields: map[string]string{"content": `dog<script>alert("dog")</script> & <b>dog</b>dog`},
+			terms:  []string{"dog"},
+			want:   `<mark>dog</mark>&lt;script&gt;alert(&#34;<mark>dog</mark>&#34;)&lt;/script&gt; &amp; &lt;b&gt;<mark>dog</mark>&lt;/b&gt;<mark>dog</mark>`,
+		},
+		{
+			name:   "no match",
+			fields: map[string]string{"content": "cat"},
+			terms:  []string{"dog"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Snippet(tt.fields, tt.terms); got != tt.want {
+			t.Errorf("%s: Snippet() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("<b>[dog]</b> & [cat]", "[", "]")
+	want := "&lt;b&gt;<mark>dog</mark>&lt;/b&gt; &amp; <mark>cat</mark>"
+	if got != want {
+		t.Errorf("Highlight() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"journal-backend/apierror"
+	"journal-backend/logging"
+	"journal-backend/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getMoodStats answers GET /stats/moods?from=...&to=...&tz=...&period=...
+// with the distribution of emotion colors of the caller's journal entries.
+// from and to are days in the IANA timezone tz; by default the last 30 days
+// in UTC are grouped by day.
+func getMoodStats(c *gin.Context) {
+	logging.Log.Debug("Received GET-Request for mood statistics")
+
+	from, to, loc, ok := statsRange(c, models.DefaultStatsDays)
+	if !ok {
+		return
+	}
+
+	period := c.DefaultQuery("period", models.PeriodDay)
+
+	stats, err := models.MoodStatistics(userStore(c), models.MoodQuery{
+		UserID:   currentUserID(c),
+		From:     from,
+		To:       to,
+		Location: loc,
+		Period:   period,
+	})
+	if errors.Is(err, models.ErrInvalidStatsQuery) {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to compute mood statistics"))
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// getStreaks answers GET /stats/streaks?from=...&to=...&tz=... with the
+// current and longest streak of days on which the caller wrote any entry,
+// and a heatmap of the active days between from and to. By default the
+// heatmap covers the last year.
+func getStreaks(c *gin.Context) {
+	logging.Log.Debug("Received GET-Request for streaks")
+
+	from, to, loc, ok := statsRange(c, models.DefaultHeatmapDays)
+	if !ok {
+		return
+	}
+
+	result, err := streaks.Streaks(userStore(c), currentUserID(c), loc, from, to)
+	if errors.Is(err, models.ErrInvalidStatsQuery) {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Wrap(err, apierror.Internal, "Failed to compute streaks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// statsRange parses the tz, from and to query parameters of the statistics
+// endpoints. tz defaults to the timezone of the caller's latest entry, to
+// to today in tz and from to the days before it.
+func statsRange(c *gin.Context, days int) (from, to time.Time, loc *time.Location, ok bool) {
+	loc, ok = requestLocation(c)
+	if !ok {
+		return from, to, nil, false
+	}
+
+	var err error
+	now := time.Now().In(loc)
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if sTo := c.Query("to"); sTo != "" {
+		to, err = time.ParseInLocation("2006-01-02", sTo, loc)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid to, expected YYYY-MM-DD"))
+			return from, to, nil, false
+		}
+	}
+
+	from = to.AddDate(0, 0, -(days - 1))
+	if sFrom := c.Query("from"); sFrom != "" {
+		from, err = time.ParseInLocation("2006-01-02", sFrom, loc)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid from, expected YYYY-MM-DD"))
+			return from, to, nil, false
+		}
+	}
+
+	if to.Before(from) {
+		apierror.Respond(c, apierror.New(apierror.InvalidRequest, "Invalid range, from is after to"))
+		return from, to, nil, false
+	}
+
+	return from, to, loc, true
+}
@@ -0,0 +1,335 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeLayouts are the formats created_at is stored in.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Memory keeps all data in process memory. It applies the same filtering,
+// ordering and ownership rules as the Supabase store and is meant for
+// tests and local development without external services.
+type Memory struct {
+	mu       sync.RWMutex
+	tables   map[string][]map[string]interface{}
+	nextID   map[string]int
+	profiles []map[string]interface{}
+}
+
+// NewMemory creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		tables: make(map[string][]map[string]interface{}),
+		nextID: make(map[string]int),
+	}
+}
+
+func (m *Memory) Select(q Query) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var rows []map[string]interface{}
+	for _, row := range m.tables[q.Table] {
+		if matches(row, q) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return isAfter(rows[j], RowCursor(rows[i]), q.Ascending)
+	})
+
+	if q.Limit > 0 && len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+	}
+
+	result := []map[string]interface{}{}
+	for _, row := range rows {
+		result = append(result, project(row, q.Columns))
+	}
+
+	return result, nil
+}
+
+func (m *Memory) Get(table, columns string, id int, userID string) (map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, row := range m.tables[table] {
+		if rowID(row) == id && row["user_id"] == userID {
+			return project(row, columns), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *Memory) Insert(table string, row map[string]interface{}) (map[string]interface{}, error) {
+	stored, err := normalize(row)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.appendLocked(table, stored)
+
+	return project(stored, "*"), nil
+}
+
+// appendLocked gives row an id and adds it to table. m.mu must be held.
+func (m *Memory) appendLocked(table string, row map[string]interface{}) {
+	m.nextID[table]++
+	row["id"] = float64(m.nextID[table])
+	if _, ok := row["created_at"]; !ok {
+		row["created_at"] = time.Now().UTC().Format(time.RFC3339)
+	}
+	m.tables[table] = append(m.tables[table], row)
+}
+
+func (m *Memory) Update(table string, id int, userID string, values map[string]interface{}) error {
+	changes, err := normalize(values)
+	if err != nil {
+		return err
+	}
+	delete(changes, "id")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, row := range m.tables[table] {
+		if rowID(row) == id && row["user_id"] == userID {
+			for k, v := range changes {
+				row[k] = v
+			}
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (m *Memory) Delete(table string, id int, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, row := range m.tables[table] {
+		if rowID(row) == id && row["user_id"] == userID {
+			m.tables[table] = append(m.tables[table][:i], m.tables[table][i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (m *Memory) ExpireLetGo(e LetGoExpiry) ([]ExpiredLetGo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	expired := []ExpiredLetGo{}
+
+	for _, row := range m.tables["moon_entries"] {
+		userID, _ := row["user_id"].(string)
+		if row["let_go"] == nil || !createdAt(row).Before(e.Before) || !e.covers(userID) {
+			continue
+		}
+
+		record := ExpiredLetGo{
+			UserID:    userID,
+			EntryID:   rowID(row),
+			Action:    letGoAction(e.Archive),
+			CreatedAt: fmt.Sprint(row["created_at"]),
+		}
+
+		if e.Archive {
+			m.appendLocked("let_go_archive", map[string]interface{}{
+				"user_id":          userID,
+				"entry_id":         float64(record.EntryID),
+				"let_go":           row["let_go"],
+				"entry_created_at": record.CreatedAt,
+				"archived_at":      now,
+			})
+		}
+		row["let_go"] = nil
+		m.appendLocked("let_go_audit", map[string]interface{}{
+			"user_id":          userID,
+			"entry_id":         float64(record.EntryID),
+			"action":           record.Action,
+			"entry_created_at": record.CreatedAt,
+			"removed_at":       now,
+		})
+
+		expired = append(expired, record)
+	}
+
+	return expired, nil
+}
+
+func (m *Memory) SelectProfiles(columns string) ([]map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := []map[string]interface{}{}
+	for _, profile := range m.profiles {
+		result = append(result, project(profile, columns))
+	}
+
+	return result, nil
+}
+
+func (m *Memory) InsertProfile(profile interface{}) error {
+	stored, err := normalize(profile)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.profiles {
+		if existing["user_id"] == stored["user_id"] {
+			return fmt.Errorf("profile for user %v already exists", stored["user_id"])
+		}
+	}
+	m.profiles = append(m.profiles, stored)
+
+	return nil
+}
+
+func (m *Memory) LetGoPolicies() ([]LetGoPolicy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	policies := []LetGoPolicy{}
+	for _, profile := range m.profiles {
+		if policy := letGoPolicy(profile); policy != nil {
+			policies = append(policies, *policy)
+		}
+	}
+
+	return policies, nil
+}
+
+func (m *Memory) GetLetGoPolicy(userID string) (*LetGoPolicy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, profile := range m.profiles {
+		if profile["user_id"] == userID {
+			return letGoPolicy(profile), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *Memory) SetLetGoPolicy(userID string, policy *LetGoPolicy) error {
+	values, err := normalize(letGoPolicyValues(policy))
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, profile := range m.profiles {
+		if profile["user_id"] == userID {
+			for column, value := range values {
+				profile[column] = value
+			}
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+// project copies the given columns of row, or all of them for "*".
+func project(row map[string]interface{}, columns string) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	if strings.TrimSpace(columns) == "*" {
+		for k, v := range row {
+			result[k] = v
+		}
+		return result
+	}
+
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		if v, ok := row[column]; ok {
+			result[column] = v
+		}
+	}
+
+	return result
+}
+
+func rowID(row map[string]interface{}) int {
+	id, _ := row["id"].(float64)
+	return int(id)
+}
+
+// matches reports whether row is selected by the filters of q.
+func matches(row map[string]interface{}, q Query) bool {
+	if row["user_id"] != q.UserID {
+		return false
+	}
+
+	for column, value := range q.Equal {
+		if fmt.Sprint(row[column]) != value {
+			return false
+		}
+	}
+
+	t := createdAt(row)
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.Until.IsZero() && !t.Before(q.Until) {
+		return false
+	}
+
+	return q.After == nil || isAfter(row, *q.After, q.Ascending)
+}
+
+// isAfter reports whether row comes after the cursor position in the
+// newest-first ordering, or oldest-first if ascending is set.
+func isAfter(row map[string]interface{}, cursor Cursor, ascending bool) bool {
+	rowTime, cursorTime := createdAt(row), ParseTime(cursor.CreatedAt)
+	if !rowTime.Equal(cursorTime) {
+		return rowTime.Before(cursorTime) != ascending
+	}
+	if ascending {
+		return rowID(row) > cursor.ID
+	}
+	return rowID(row) < cursor.ID
+}
+
+func createdAt(row map[string]interface{}) time.Time {
+	s, _ := row["created_at"].(string)
+	return ParseTime(s)
+}
+
+// ParseTime parses a created_at value in any of the formats it is stored in.
+// It returns the zero time for anything else.
+func ParseTime(s string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	owner    = "11111111-1111-1111-1111-111111111111"
+	stranger = "22222222-2222-2222-2222-222222222222"
+)
+
+// fixture stores journal entries given as user|created_at|color and
+// returns the store. Ids follow the order of rows.
+func fixture(t *testing.T, rows ...string) *Memory {
+	t.Helper()
+	m := NewMemory()
+	for _, row := range rows {
+		fields := strings.Split(row, "|")
+		if len(fields) != 3 {
+			t.Fatalf("fixture %q: want user|created_at|color", row)
+		}
+		userID, createdAt, color := fields[0], fields[1], fields[2]
+		_, err := m.Insert("journal_entries", map[string]interface{}{
+			"user_id": userID, "created_at": createdAt, "emotion_color": color, "content": "entry",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func ids(rows []map[string]interface{}) []int {
+	result := make([]int, len(rows))
+	for i, row := range rows {
+		result[i] = rowID(row)
+	}
+	return result
+}
+
+func TestMemoryOwnership(t *testing.T) {
+	m := fixture(t,
+		owner+"|2024-05-01T08:00:00Z|red",
+		stranger+"|2024-05-02T08:00:00Z|blue",
+	)
+
+	if _, err := m.Get("journal_entries", "*", 2, owner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of another user's row: error = %v, want ErrNotFound", err)
+	}
+	if row, err := m.Get("journal_entries", "id,emotion_color", 1, owner); err != nil || fmt.Sprint(row) != "map[emotion_color:red id:1]" {
+		t.Errorf("Get() of an own row = %v, %v, want map[emotion_color:red id:1]", row, err)
+	}
+
+	if err := m.Update("journal_entries", 2, owner, map[string]interface{}{"emotion_color": "grey"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update() of another user's row: error = %v, want ErrNotFound", err)
+	}
+	if err := m.Delete("journal_entries", 2, owner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of another user's row: error = %v, want ErrNotFound", err)
+	}
+	row, err := m.Get("journal_entries", "emotion_color", 2, stranger)
+	if err != nil || row["emotion_color"] != "blue" {
+		t.Errorf("another user's row after Update() and Delete() = %v, %v, want it unchanged", row, err)
+	}
+
+	// The id of a row cannot be changed.
+	if err := m.Update("journal_entries", 1, owner, map[string]interface{}{"id": 5, "emotion_color": "grey"}); err != nil {
+		t.Fatal(err)
+	}
+	if row, err := m.Get("journal_entries", "emotion_color", 1, owner); err != nil || row["emotion_color"] != "grey" {
+		t.Errorf("row after Update() = %v, %v, want emotion_color grey", row, err)
+	}
+
+	rows, _ := m.Select(Query{Table: "journal_entries", Columns: "*", UserID: owner})
+	if got := ids(rows); fmt.Sprint(got) != "[1]" {
+		t.Errorf("Select() = ids %v, want only the own row [1]", got)
+	}
+
+	if err := m.Delete("journal_entries", 1, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get("journal_entries", "*", 1, owner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete(): error = %v, want ErrNotFound", err)
+	}
+	if err := m.Delete("journal_entries", 1, owner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete(): error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemorySelect(t *testing.T) {
+	m := fixture(t,
+		owner+"|2024-05-01T08:00:00Z|red",        // 1
+		owner+"|2024-05-02T08:00:00Z|blue",       // 2
+		owner+"|2024-05-02T08:00:00Z|red",        // 3, same time as 2
+		owner+"|2024-05-02T09:30:00+02:00|green", // 4, 07:30 UTC
+		owner+"|2024-05-03 10:00:00|red",         // 5
+		owner+"|2024-05-04|blue",                 // 6
+		owner+"|2024-05-04T23:59:59.999999Z|red", // 7
+		stranger+"|2024-05-02T08:00:00Z|red",     // 8
+		owner+"|2024-05-02T08:00:00.000Z|grey",   // 9, same time as 2
+	)
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		{
+			name: "newest first, ties by id",
+			q:    Query{},
+			want: []int{7, 6, 5, 9, 3, 2, 4, 1},
+		},
+		{
+			name: "oldest first",
+			q:    Query{Ascending: true},
+			want: []int{1, 4, 2, 3, 9, 5, 6, 7},
+		},
+		{
+			name: "equal",
+			q:    Query{Equal: map[string]string{"emotion_color": "red"}},
+			want: []int{7, 5, 3, 1},
+		},
+		{
+			name: "several columns equal",
+			q:    Query{Equal: map[string]string{"emotion_color": "red", "id": "3"}},
+			want: []int{3},
+		},
+		{
+			name: "from is included, until is not",
+			q:    Query{From: day("2024-05-02").Add(8 * time.Hour), Until: day("2024-05-04")},
+			want: []int{5, 9, 3, 2},
+		},
+		{
+			name: "until the end of a day",
+			q:    Query{From: day("2024-05-04"), Until: day("2024-05-05")},
+			want: []int{7, 6},
+		},
+		{
+			name: "limit",
+			q:    Query{Limit: 3},
+			want: []int{7, 6, 5},
+		},
+		{
+			name: "after a tie",
+			q:    Query{After: &Cursor{CreatedAt: "2024-05-02T08:00:00Z", ID: 3}},
+			want: []int{2, 4, 1},
+		},
+		{
+			name: "after a tie, oldest first",
+			q:    Query{Ascending: true, After: &Cursor{CreatedAt: "2024-05-02T08:00:00Z", ID: 3}},
+			want: []int{9, 5, 6, 7},
+		},
+		{
+			name: "after with filters and limit",
+			q:    Query{Equal: map[string]string{"emotion_color": "red"}, After: &Cursor{CreatedAt: "2024-05-04T00:00:00Z", ID: 6}, Limit: 2},
+			want: []int{5, 3},
+		},
+		{
+			name: "other table",
+			q:    Query{Table: "moon_entries"},
+			want: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.q.Table == "" {
+			tt.q.Table = "journal_entries"
+		}
+		tt.q.Columns, tt.q.UserID = "id", owner
+
+		rows, err := m.Select(tt.q)
+		if err != nil {
+			t.Fatalf("%s: Select() error = %v", tt.name, err)
+		}
+		if got := ids(rows); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: Select() = ids %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryColumns(t *testing.T) {
+	m := fixture(t, owner+"|2024-05-01T08:00:00Z|red")
+
+	tests := []struct {
+		columns string
+		want    string
+	}{
+		{columns: "id, emotion_color", want: "map[emotion_color:red id:1]"},
+		{columns: "id,unknown", want: "map[id:1]"},
+		{columns: " * ", want: "map[content:entry created_at:2024-05-01T08:00:00Z emotion_color:red id:1 user_id:" + owner + "]"},
+	}
+
+	for _, tt := range tests {
+		rows, err := m.Select(Query{Table: "journal_entries", Columns: tt.columns, UserID: owner})
+		if err != nil || len(rows) != 1 {
+			t.Fatalf("Select(%q) = %v, %v", tt.columns, rows, err)
+		}
+		if got := fmt.Sprint(rows[0]); got != tt.want {
+			t.Errorf("Select(%q) = %s, want %s", tt.columns, got, tt.want)
+		}
+
+		// Changing a result leaves the stored row alone.
+		rows[0]["emotion_color"] = "grey"
+	}
+
+	row, _ := m.Get("journal_entries", "emotion_color", 1, owner)
+	if row["emotion_color"] != "red" {
+		t.Errorf("stored row changed through a result to %v", row["emotion_color"])
+	}
+}
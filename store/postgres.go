@@ -0,0 +1,454 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"journal-backend/search"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Postgres talks to a self-hosted PostgreSQL database directly. There is no
+// row level security, so every query filters on user_id itself.
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres connects to the database described by dsn.
+func NewPostgres(dsn string) (*Postgres, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Postgres{db: conn}, nil
+}
+
+// DB returns the underlying connection pool.
+func (p *Postgres) DB() *sql.DB {
+	return p.db
+}
+
+// Close closes the connection pool.
+func (p *Postgres) Close() error {
+	return p.db.Close()
+}
+
+func (p *Postgres) Select(q Query) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1",
+		selectList(q.Columns), pq.QuoteIdentifier(q.Table))
+	args := []interface{}{q.UserID}
+
+	columns := make([]string, 0, len(q.Equal))
+	for column := range q.Equal {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		args = append(args, q.Equal[column])
+		query += fmt.Sprintf(" AND %s = $%d", pq.QuoteIdentifier(column), len(args))
+	}
+
+	if !q.From.IsZero() {
+		args = append(args, q.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, q.Until)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	direction, op := "DESC", "<"
+	if q.Ascending {
+		direction, op = "ASC", ">"
+	}
+	if q.After != nil {
+		args = append(args, q.After.CreatedAt, q.After.ID)
+		query += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", op, len(args)-1, len(args))
+	}
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s", direction, direction)
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return p.query(query, args...)
+}
+
+func (p *Postgres) Get(table, columns string, id int, userID string) (map[string]interface{}, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND user_id = $2",
+		selectList(columns), pq.QuoteIdentifier(table))
+
+	result, err := p.query(query, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+	return result[0], nil
+}
+
+func (p *Postgres) Insert(table string, row map[string]interface{}) (map[string]interface{}, error) {
+	row = withoutKeys(row, "id")
+
+	columns, args := columnsAndArgs(row)
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING *",
+		pq.QuoteIdentifier(table), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	inserted, err := p.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return inserted[0], nil
+}
+
+func (p *Postgres) Update(table string, id int, userID string, values map[string]interface{}) error {
+	values = withoutKeys(values, "id")
+	if len(values) == 0 {
+		// Nothing to change, but the caller still needs to know if the row exists.
+		values = map[string]interface{}{"id": id}
+	}
+
+	columns, args := columnsAndArgs(values)
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	args = append(args, id, userID)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND user_id = $%d",
+		pq.QuoteIdentifier(table), strings.Join(assignments, ", "), len(args)-1, len(args))
+
+	result, err := p.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *Postgres) Delete(table string, id int, userID string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2", pq.QuoteIdentifier(table))
+
+	result, err := p.db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ExpireLetGo archives, clears and audits the items in a single statement,
+// so either all of it happens or nothing.
+func (p *Postgres) ExpireLetGo(e LetGoExpiry) ([]ExpiredLetGo, error) {
+	users := "NOT (user_id = ANY($3::uuid[]))"
+	ids := e.ExceptUserIDs
+	if len(e.UserIDs) > 0 {
+		users = "user_id = ANY($3::uuid[])"
+		ids = e.UserIDs
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+
+	rows, err := p.db.Query(`
+		WITH expired AS (
+			SELECT id, user_id, let_go, created_at FROM moon_entries
+			WHERE created_at < $1 AND let_go IS NOT NULL AND `+users+`
+			FOR UPDATE
+		), archived AS (
+			INSERT INTO let_go_archive (user_id, entry_id, let_go, entry_created_at)
+			SELECT user_id, id, let_go, created_at FROM expired WHERE $2 = 'archived'
+		), cleared AS (
+			UPDATE moon_entries SET let_go = NULL
+			WHERE id IN (SELECT id FROM expired)
+		)
+		INSERT INTO let_go_audit (user_id, entry_id, action, entry_created_at)
+		SELECT user_id, id, $2, created_at FROM expired
+		RETURNING user_id, entry_id, action, entry_created_at`,
+		e.Before, letGoAction(e.Archive), pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expired := []ExpiredLetGo{}
+	for rows.Next() {
+		var record ExpiredLetGo
+		var createdAt time.Time
+		if err := rows.Scan(&record.UserID, &record.EntryID, &record.Action, &createdAt); err != nil {
+			return nil, err
+		}
+		record.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		expired = append(expired, record)
+	}
+
+	return expired, rows.Err()
+}
+
+// Markers ts_headline puts around matches, here and in the search_entries
+// function. They are characters of the private use area, which entries do
+// not contain and HTML escaping keeps.
+const (
+	headlineStart = "\ue000"
+	headlineEnd   = "\ue001"
+)
+
+// Search ranks entries with PostgreSQL full-text search. The tsvector
+// expressions match the indexes of migration 0002_search_indexes.
+func (p *Postgres) Search(q SearchQuery) ([]SearchHit, error) {
+	tsQuery := strings.Join(q.Terms, " | ")
+	var hits []SearchHit
+
+	for _, t := range q.Tables {
+		parts := make([]string, len(t.Fields))
+		for i, field := range t.Fields {
+			parts[i] = fmt.Sprintf("coalesce(%s::text, '')", pq.QuoteIdentifier(field))
+		}
+		document := strings.Join(parts, " || ' ' || ")
+
+		query := fmt.Sprintf(`SELECT id, created_at,
+				ts_rank(to_tsvector('simple', %[1]s), query) AS rank,
+				ts_headline('simple', %[1]s, query, %[3]s) AS snippet
+			FROM %[2]s, to_tsquery('simple', $2) query
+			WHERE user_id = $1 AND to_tsvector('simple', %[1]s) @@ query
+			ORDER BY rank DESC, id DESC
+			LIMIT $3`, document, pq.QuoteIdentifier(t.Table),
+			pq.QuoteLiteral("StartSel="+headlineStart+", StopSel="+headlineEnd+", MaxWords=20, MinWords=5"))
+
+		rows, err := p.query(query, q.UserID, tsQuery, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			cursor := RowCursor(row)
+			rank, _ := row["rank"].(float64)
+			snippet, _ := row["snippet"].(string)
+			hits = append(hits, SearchHit{
+				Table:     t.Table,
+				ID:        cursor.ID,
+				CreatedAt: cursor.CreatedAt,
+				Rank:      rank,
+				Snippet:   search.Highlight(snippet, headlineStart, headlineEnd),
+			})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Rank > hits[j].Rank
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+
+	return hits, nil
+}
+
+func (p *Postgres) SelectProfiles(columns string) ([]map[string]interface{}, error) {
+	return p.query(fmt.Sprintf("SELECT %s FROM profiles", selectList(columns)))
+}
+
+func (p *Postgres) InsertProfile(profile interface{}) error {
+	row, err := normalize(profile)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.Insert("profiles", row)
+	return err
+}
+
+func (p *Postgres) LetGoPolicies() ([]LetGoPolicy, error) {
+	rows, err := p.query(fmt.Sprintf("SELECT %s FROM profiles WHERE let_go_expire_hours IS NOT NULL",
+		selectList(letGoPolicyColumns)))
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []LetGoPolicy{}
+	for _, row := range rows {
+		if policy := letGoPolicy(row); policy != nil {
+			policies = append(policies, *policy)
+		}
+	}
+	return policies, nil
+}
+
+func (p *Postgres) GetLetGoPolicy(userID string) (*LetGoPolicy, error) {
+	rows, err := p.query(fmt.Sprintf("SELECT %s FROM profiles WHERE user_id = $1",
+		selectList(letGoPolicyColumns)), userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return letGoPolicy(rows[0]), nil
+}
+
+func (p *Postgres) SetLetGoPolicy(userID string, policy *LetGoPolicy) error {
+	values := letGoPolicyValues(policy)
+
+	result, err := p.db.Exec("UPDATE profiles SET let_go_expire_hours = $1, let_go_archive = $2 WHERE user_id = $3",
+		values["let_go_expire_hours"], values["let_go_archive"], userID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// query runs a SELECT and returns its rows in the form PostgREST returns them.
+func (p *Postgres) query(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(types))
+		pointers := make([]interface{}, len(types))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(types))
+		for i, columnType := range types {
+			value, err := fromColumn(columnType.DatabaseTypeName(), values[i])
+			if err != nil {
+				return nil, err
+			}
+			row[columnType.Name()] = value
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// fromColumn converts a scanned value into its JSON representation.
+func fromColumn(databaseType string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []byte:
+		if databaseType == "JSON" || databaseType == "JSONB" {
+			var decoded interface{}
+			if err := json.Unmarshal(v, &decoded); err != nil {
+				return nil, err
+			}
+			return decoded, nil
+		}
+		return string(v), nil
+	case time.Time:
+		if databaseType == "DATE" {
+			return v.Format("2006-01-02"), nil
+		}
+		return v.UTC().Format(time.RFC3339Nano), nil
+	default:
+		return v, nil
+	}
+}
+
+// toColumn converts a JSON value into something the driver can store.
+// Objects and arrays end up in json/jsonb columns.
+func toColumn(value interface{}) interface{} {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		// Values decoded from JSON can always be encoded again.
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	default:
+		return value
+	}
+}
+
+// columnsAndArgs returns the quoted column names of row in a stable order
+// together with the matching query arguments.
+func columnsAndArgs(row map[string]interface{}) ([]string, []interface{}) {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	columns := make([]string, len(keys))
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		columns[i] = pq.QuoteIdentifier(k)
+		args[i] = toColumn(row[k])
+	}
+
+	return columns, args
+}
+
+// selectList quotes every column of a comma separated list.
+func selectList(columns string) string {
+	if strings.TrimSpace(columns) == "*" {
+		return "*"
+	}
+
+	quoted := []string{}
+	for _, column := range strings.Split(columns, ",") {
+		quoted = append(quoted, pq.QuoteIdentifier(strings.TrimSpace(column)))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func withoutKeys(row map[string]interface{}, keys ...string) map[string]interface{} {
+	result := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		result[k] = v
+	}
+	for _, k := range keys {
+		delete(result, k)
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrNotFound is returned when a row does not exist or belongs to someone else.
+var ErrNotFound = errors.New("entry not found")
+
+// Query selects rows of one user from an entry table. Rows are ordered by
+// created_at and id, newest first unless Ascending is set.
+type Query struct {
+	Table string
+	// Columns is a comma separated list or "*".
+	Columns string
+	UserID  string
+	// From and Until restrict created_at to [From, Until). Zero values
+	// leave the range open.
+	From  time.Time
+	Until time.Time
+	// Equal holds column/value pairs rows must match. Column names must be
+	// validated by the caller.
+	Equal map[string]string
+	// Ascending returns the oldest rows first.
+	Ascending bool
+	// Limit caps the number of rows returned; 0 means no limit.
+	Limit int
+	// After, if set, skips all rows up to and including this position.
+	After *Cursor
+}
+
+// Cursor is a position in the created_at/id ordering of a table.
+type Cursor struct {
+	CreatedAt string `json:"c"`
+	ID        int    `json:"i"`
+}
+
+// RowCursor returns the position of row, which must contain created_at and id.
+func RowCursor(row map[string]interface{}) Cursor {
+	cursor := Cursor{}
+	cursor.CreatedAt, _ = row["created_at"].(string)
+
+	// PostgREST rows carry JSON numbers, rows scanned by database/sql int64.
+	switch id := row["id"].(type) {
+	case float64:
+		cursor.ID = int(id)
+	case int64:
+		cursor.ID = int(id)
+	}
+
+	return cursor
+}
+
+// EntryStore reads and writes rows of the entry tables (journal_entries,
+// moon_entries, relationship_check).
+type EntryStore interface {
+	// Select returns the rows described by q, newest first.
+	Select(q Query) ([]map[string]interface{}, error)
+	// Get returns the given columns of the row with id in table if it
+	// belongs to userID. It returns ErrNotFound if there is no such row.
+	Get(table, columns string, id int, userID string) (map[string]interface{}, error)
+	// Insert adds row to table and returns it as stored, including its id.
+	Insert(table string, row map[string]interface{}) (map[string]interface{}, error)
+	// Update sets values on the row with id in table if it belongs to userID.
+	// It returns ErrNotFound if there is no such row.
+	Update(table string, id int, userID string, values map[string]interface{}) error
+	// Delete removes the row with id from table if it belongs to userID.
+	// It returns ErrNotFound if there is no such row.
+	Delete(table string, id int, userID string) error
+	// ExpireLetGo resets let_go of the moon entries selected by e, copies
+	// the items to the let-go archive first if e asks for it, and writes an
+	// audit record for every entry. It returns the audit records.
+	ExpireLetGo(e LetGoExpiry) ([]ExpiredLetGo, error)
+}
+
+// ProfileStore reads and writes rows of the profiles table.
+type ProfileStore interface {
+	// SelectProfiles returns the given columns of all profiles.
+	SelectProfiles(columns string) ([]map[string]interface{}, error)
+	// InsertProfile adds a new profile.
+	InsertProfile(profile interface{}) error
+	// LetGoPolicies returns the let-go policies of all profiles that have one.
+	LetGoPolicies() ([]LetGoPolicy, error)
+	// GetLetGoPolicy returns the let-go policy of userID, or nil if the
+	// profile has none. It returns ErrNotFound if there is no profile.
+	GetLetGoPolicy(userID string) (*LetGoPolicy, error)
+	// SetLetGoPolicy changes the let-go policy of userID; nil removes it.
+	// It returns ErrNotFound if there is no profile.
+	SetLetGoPolicy(userID string, policy *LetGoPolicy) error
+}
+
+// LetGoPolicy is how long the let-go items of a user are kept and what
+// happens to them afterwards.
+type LetGoPolicy struct {
+	UserID      string
+	ExpireAfter time.Duration
+	// Archive keeps expired items in the let-go archive instead of
+	// deleting them.
+	Archive bool
+}
+
+// LetGoExpiry selects the let-go items removed in one pass.
+type LetGoExpiry struct {
+	// Before is the creation time items must be older than.
+	Before  time.Time
+	Archive bool
+	// UserIDs limits the pass to these users. If it is empty, the pass
+	// covers all users except ExceptUserIDs.
+	UserIDs       []string
+	ExceptUserIDs []string
+}
+
+// Audit actions of expired let-go items.
+const (
+	LetGoDeleted  = "deleted"
+	LetGoArchived = "archived"
+)
+
+// ExpiredLetGo is the audit record of one let-go item that was removed.
+type ExpiredLetGo struct {
+	UserID    string `json:"user_id"`
+	EntryID   int    `json:"entry_id"`
+	Action    string `json:"action"`
+	CreatedAt string `json:"entry_created_at"`
+}
+
+// covers reports whether the pass includes the items of userID.
+func (e LetGoExpiry) covers(userID string) bool {
+	if len(e.UserIDs) > 0 {
+		return slices.Contains(e.UserIDs, userID)
+	}
+	return !slices.Contains(e.ExceptUserIDs, userID)
+}
+
+// letGoAction returns the audit action of a pass.
+func letGoAction(archive bool) string {
+	if archive {
+		return LetGoArchived
+	}
+	return LetGoDeleted
+}
+
+// letGoPolicyColumns are the columns of profiles holding the let-go policy.
+// A NULL let_go_expire_hours means the profile has no policy.
+const letGoPolicyColumns = "user_id,let_go_expire_hours,let_go_archive"
+
+// letGoPolicy reads the let-go policy from a profile row.
+func letGoPolicy(row map[string]interface{}) *LetGoPolicy {
+	hours, ok := row["let_go_expire_hours"].(float64)
+	if !ok {
+		if h, isInt := row["let_go_expire_hours"].(int64); isInt {
+			hours, ok = float64(h), true
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	userID, _ := row["user_id"].(string)
+	archive, _ := row["let_go_archive"].(bool)
+	return &LetGoPolicy{UserID: userID, ExpireAfter: time.Duration(hours) * time.Hour, Archive: archive}
+}
+
+// letGoPolicyValues are the profile columns that store policy.
+func letGoPolicyValues(policy *LetGoPolicy) map[string]interface{} {
+	if policy == nil {
+		return map[string]interface{}{"let_go_expire_hours": nil, "let_go_archive": false}
+	}
+	return map[string]interface{}{
+		"let_go_expire_hours": int(policy.ExpireAfter / time.Hour),
+		"let_go_archive":      policy.Archive,
+	}
+}
+
+// SearchQuery asks for the entries of one user whose Fields match Terms.
+type SearchQuery struct {
+	UserID string
+	// Terms are normalized words; an entry matches if it contains any of them.
+	Terms  []string
+	Tables []SearchTable
+	Limit  int
+}
+
+// SearchTable names the text fields of one table that are searched.
+type SearchTable struct {
+	Table  string
+	Fields []string
+}
+
+// SearchHit is one entry found by a search.
+type SearchHit struct {
+	Table     string
+	ID        int
+	CreatedAt string
+	Rank      float64
+	Snippet   string
+}
+
+// Searcher is implemented by stores with their own full-text search.
+// Stores without it are searched in process.
+type Searcher interface {
+	Search(q SearchQuery) ([]SearchHit, error)
+}
+
+// Store bundles everything the handlers need to persist data.
+type Store interface {
+	EntryStore
+	ProfileStore
+}
+
+// normalize converts v into the generic form PostgREST would return it in,
+// which also gives the store its own copy of the data.
+func normalize(v interface{}) (map[string]interface{}, error) {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,354 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journal-backend/db"
+	"journal-backend/search"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+// Supabase stores data through PostgREST with the session of one user, so
+// all queries are subject to that user's row level security.
+type Supabase struct {
+	client *db.Client
+}
+
+// NewSupabase creates a store working with the session of dbClient.
+func NewSupabase(dbClient *db.Client) *Supabase {
+	return &Supabase{client: dbClient}
+}
+
+func (s *Supabase) Select(q Query) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+
+	order := &postgrest.OrderOpts{Ascending: q.Ascending}
+
+	query := s.client.
+		From(q.Table).
+		Select(q.Columns, "", false).
+		Eq("user_id", q.UserID).
+		Order("created_at", order).
+		Order("id", order)
+
+	for column, value := range q.Equal {
+		query = query.Eq(column, value)
+	}
+
+	// created_at can only appear once as a parameter, so the bounds are
+	// combined in one and=(...) filter.
+	var bounds []string
+	if !q.From.IsZero() {
+		bounds = append(bounds, fmt.Sprintf(`created_at.gte."%s"`, formatTime(q.From)))
+	}
+	if !q.Until.IsZero() {
+		bounds = append(bounds, fmt.Sprintf(`created_at.lt."%s"`, formatTime(q.Until)))
+	}
+	if len(bounds) > 0 {
+		query = query.And(strings.Join(bounds, ","), "")
+	}
+
+	if q.After != nil {
+		op := "lt"
+		if q.Ascending {
+			op = "gt"
+		}
+		query = query.Or(fmt.Sprintf(`created_at.%s."%s",and(created_at.eq."%s",id.%s.%d)`,
+			op, q.After.CreatedAt, q.After.CreatedAt, op, q.After.ID), "")
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit, "")
+	}
+
+	_, err := query.ExecuteTo(&result)
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Supabase) Get(table, columns string, id int, userID string) (map[string]interface{}, error) {
+	var result []map[string]interface{}
+
+	_, err := s.client.
+		From(table).
+		Select(columns, "", false).
+		Eq("id", strconv.Itoa(id)).
+		Eq("user_id", userID).
+		ExecuteTo(&result)
+
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+	return result[0], nil
+}
+
+func (s *Supabase) Insert(table string, row map[string]interface{}) (map[string]interface{}, error) {
+	var inserted []map[string]interface{}
+
+	_, err := s.client.
+		From(table).
+		Insert(row, false, "", "representation", "").
+		ExecuteTo(&inserted)
+
+	if err != nil {
+		return nil, err
+	}
+	if len(inserted) == 0 {
+		return row, nil
+	}
+	return inserted[0], nil
+}
+
+func (s *Supabase) Update(table string, id int, userID string, values map[string]interface{}) error {
+	var updated []map[string]interface{}
+
+	_, err := s.client.
+		From(table).
+		Update(values, "", "").
+		Eq("id", strconv.Itoa(id)).
+		Eq("user_id", userID).
+		ExecuteTo(&updated)
+
+	if err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Supabase) Delete(table string, id int, userID string) error {
+	var deleted []map[string]interface{}
+
+	_, err := s.client.
+		From(table).
+		Delete("", "exact").
+		Eq("id", strconv.Itoa(id)).
+		Eq("user_id", userID).
+		ExecuteTo(&deleted)
+
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ExpireLetGo works in separate requests, as PostgREST has no transactions
+// across tables. Items are archived before they are cleared, so a failure
+// never loses an item that should have been kept.
+func (s *Supabase) ExpireLetGo(e LetGoExpiry) ([]ExpiredLetGo, error) {
+	var rows []map[string]interface{}
+
+	query := s.client.
+		From("moon_entries").
+		Select("id,user_id,let_go,created_at", "", false).
+		Lt("created_at", formatTime(e.Before)). // Einträge älter als before
+		Not("let_go", "is", "null")             // nur Einträge mit nicht-NULL `let_go`
+	if len(e.UserIDs) > 0 {
+		query = query.In("user_id", e.UserIDs)
+	} else if len(e.ExceptUserIDs) > 0 {
+		query = query.Not("user_id", "in", "("+strings.Join(e.ExceptUserIDs, ",")+")")
+	}
+
+	if _, err := query.ExecuteTo(&rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []ExpiredLetGo{}, nil
+	}
+
+	expired := make([]ExpiredLetGo, len(rows))
+	ids := make([]string, len(rows))
+	archive := make([]map[string]interface{}, len(rows))
+	audit := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		cursor := RowCursor(row)
+		userID, _ := row["user_id"].(string)
+
+		expired[i] = ExpiredLetGo{UserID: userID, EntryID: cursor.ID, Action: letGoAction(e.Archive), CreatedAt: cursor.CreatedAt}
+		ids[i] = strconv.Itoa(cursor.ID)
+		archive[i] = map[string]interface{}{
+			"user_id":          userID,
+			"entry_id":         cursor.ID,
+			"let_go":           row["let_go"],
+			"entry_created_at": cursor.CreatedAt,
+		}
+		audit[i] = map[string]interface{}{
+			"user_id":          userID,
+			"entry_id":         cursor.ID,
+			"action":           expired[i].Action,
+			"entry_created_at": cursor.CreatedAt,
+		}
+	}
+
+	if e.Archive {
+		_, _, err := s.client.From("let_go_archive").Insert(archive, false, "", "minimal", "").Execute()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, _, err := s.client.
+		From("moon_entries").
+		Update(map[string]interface{}{"let_go": nil}, "minimal", "").
+		In("id", ids).
+		Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = s.client.From("let_go_audit").Insert(audit, false, "", "minimal", "").Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+func (s *Supabase) SelectProfiles(columns string) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+
+	_, err := s.client.
+		From("profiles").
+		Select(columns, "", false).
+		ExecuteTo(&result)
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Supabase) InsertProfile(profile interface{}) error {
+	_, _, err := s.client.
+		From("profiles").
+		Insert(profile, false, "", "*", "").
+		Execute()
+
+	return err
+}
+
+func (s *Supabase) LetGoPolicies() ([]LetGoPolicy, error) {
+	var rows []map[string]interface{}
+
+	_, err := s.client.
+		From("profiles").
+		Select(letGoPolicyColumns, "", false).
+		Not("let_go_expire_hours", "is", "null").
+		ExecuteTo(&rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []LetGoPolicy{}
+	for _, row := range rows {
+		if policy := letGoPolicy(row); policy != nil {
+			policies = append(policies, *policy)
+		}
+	}
+	return policies, nil
+}
+
+func (s *Supabase) GetLetGoPolicy(userID string) (*LetGoPolicy, error) {
+	var rows []map[string]interface{}
+
+	_, err := s.client.
+		From("profiles").
+		Select(letGoPolicyColumns, "", false).
+		Eq("user_id", userID).
+		ExecuteTo(&rows)
+
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return letGoPolicy(rows[0]), nil
+}
+
+func (s *Supabase) SetLetGoPolicy(userID string, policy *LetGoPolicy) error {
+	var updated []map[string]interface{}
+
+	_, err := s.client.
+		From("profiles").
+		Update(letGoPolicyValues(policy), "", "").
+		Eq("user_id", userID).
+		ExecuteTo(&updated)
+
+	if err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// searchRow is a row returned by the search_entries function.
+type searchRow struct {
+	Table     string  `json:"entry_table"`
+	ID        int     `json:"id"`
+	CreatedAt string  `json:"created_at"`
+	Rank      float64 `json:"rank"`
+	Snippet   string  `json:"snippet"`
+}
+
+// Search calls the search_entries function of migration
+// 0006_search_function, which ranks entries in the database with the
+// indexes of 0002_search_indexes. The function covers the SearchFields of
+// the entry types; q.Tables only selects the tables.
+func (s *Supabase) Search(q SearchQuery) ([]SearchHit, error) {
+	tables := make([]string, len(q.Tables))
+	for i, t := range q.Tables {
+		tables[i] = t.Table
+	}
+
+	body := s.client.Rpc("search_entries", "", map[string]interface{}{
+		"search_user": q.UserID,
+		"terms":       strings.Join(q.Terms, " | "),
+		"tables":      tables,
+		"max_results": q.Limit,
+	})
+
+	// PostgREST answers errors with an object instead of the rows, and
+	// Rpc answers failed requests with an empty body.
+	var rows []searchRow
+	if body == "" {
+		return nil, errors.New("search_entries: request failed")
+	}
+	if err := json.Unmarshal([]byte(body), &rows); err != nil {
+		return nil, fmt.Errorf("search_entries: %s", body)
+	}
+
+	hits := make([]SearchHit, len(rows))
+	for i, row := range rows {
+		hits[i] = SearchHit{
+			Table:     row.Table,
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			Rank:      row.Rank,
+			Snippet:   search.Highlight(row.Snippet, headlineStart, headlineEnd),
+		}
+	}
+	return hits, nil
+}